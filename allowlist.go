@@ -0,0 +1,172 @@
+/*
+Anon (c) 2023 by Mikhail Kondrashin (mkondrashin@gmail.com)
+github.com/mpkondrashin/anon
+
+allowlist.go
+
+Values, networks, domains and patterns that must never be anonymized
+*/
+package anon
+
+import (
+	"net/netip"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// allowlist - rules for values that must never be anonymized.
+type allowlist struct {
+	values   map[string]struct{}
+	networks []netip.Prefix
+	domains  []string
+	regexes  []*regexp.Regexp
+}
+
+// allows - return true if value of given data type matches any of the rules.
+func (l *allowlist) allows(data *confidentialData, value string) bool {
+	if l == nil {
+		return false
+	}
+	if _, ok := l.values[value]; ok {
+		return true
+	}
+	for _, regex := range l.regexes {
+		if regex.MatchString(value) {
+			return true
+		}
+	}
+	if len(l.networks) == 0 && len(l.domains) == 0 {
+		return false
+	}
+	host := hostOf(data, value)
+	if addr, err := netip.ParseAddr(strings.Trim(host, "[]")); err == nil {
+		addr = addr.Unmap()
+		for _, network := range l.networks {
+			if network.Contains(addr) {
+				return true
+			}
+		}
+		return false
+	}
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	for _, domain := range l.domains {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// clone - return copy of the allowlist that can be changed independently.
+func (l *allowlist) clone() *allowlist {
+	if l == nil {
+		return nil
+	}
+	c := &allowlist{
+		values:   make(map[string]struct{}, len(l.values)),
+		networks: append([]netip.Prefix(nil), l.networks...),
+		domains:  append([]string(nil), l.domains...),
+		regexes:  append([]*regexp.Regexp(nil), l.regexes...),
+	}
+	for value := range l.values {
+		c.values[value] = struct{}{}
+	}
+	return c
+}
+
+// hostOf - return host name or address contained in the value: domain of the Email,
+// host of the URL or value itself for other data types.
+func hostOf(data *confidentialData, value string) string {
+	if data.isCustom() {
+		return value
+	}
+	switch data.dataType {
+	case Email:
+		return value[strings.LastIndexByte(value, '@')+1:]
+	case URL:
+		if u, err := url.Parse(value); err == nil {
+			return u.Hostname()
+		}
+	}
+	return value
+}
+
+// allowlist - return allowlist for given data type creating it if necessary.
+// Nil data type pointer means allowlist for all data types.
+func (a *Anonymizer) allowlist(t *DataType) *allowlist {
+	if t == nil {
+		if a.allowAll == nil {
+			a.allowAll = &allowlist{values: make(map[string]struct{})}
+		}
+		return a.allowAll
+	}
+	if a.allowlists == nil {
+		a.allowlists = make(map[DataType]*allowlist)
+	}
+	l, ok := a.allowlists[*t]
+	if !ok {
+		l = &allowlist{values: make(map[string]struct{})}
+		a.allowlists[*t] = l
+	}
+	return l
+}
+
+// allows - return true if value detected as given data must not be anonymized
+// because of allowlists or IP policy.
+func (a *Anonymizer) allows(data *confidentialData, value string) bool {
+	if a.skipsIP(data, value) || a.allowAll.allows(data, value) {
+		return true
+	}
+	if data.isCustom() {
+		return false
+	}
+	return a.allowlists[data.dataType].allows(data, value)
+}
+
+// Allow - never anonymize given values even if they are detected as confidential data.
+func (a *Anonymizer) Allow(values ...string) *Anonymizer {
+	l := a.allowlist(nil)
+	for _, value := range values {
+		l.values[value] = struct{}{}
+	}
+	return a
+}
+
+// AllowValues - never anonymize given values detected as data of type t.
+func (a *Anonymizer) AllowValues(t DataType, values ...string) *Anonymizer {
+	l := a.allowlist(&t)
+	for _, value := range values {
+		l.values[value] = struct{}{}
+	}
+	return a
+}
+
+// AllowNetworks - never anonymize addresses of type t belonging to given networks.
+// For IP4 and IP6 value itself is checked, for URL - its host.
+func (a *Anonymizer) AllowNetworks(t DataType, networks ...netip.Prefix) *Anonymizer {
+	l := a.allowlist(&t)
+	for _, network := range networks {
+		l.networks = append(l.networks, network.Masked())
+	}
+	return a
+}
+
+// AllowDomains - never anonymize data of type t belonging to given domains or their
+// subdomains. For DNSName value itself is checked, for Email - its domain and for
+// URL - its host.
+func (a *Anonymizer) AllowDomains(t DataType, domains ...string) *Anonymizer {
+	l := a.allowlist(&t)
+	for _, domain := range domains {
+		l.domains = append(l.domains, strings.ToLower(strings.Trim(domain, ".")))
+	}
+	return a
+}
+
+// AllowRegex - never anonymize data of type t matching any of given regexes. Regex
+// can match part of the value, so use ^ and $ to match the whole value.
+func (a *Anonymizer) AllowRegex(t DataType, regexes ...*regexp.Regexp) *Anonymizer {
+	l := a.allowlist(&t)
+	l.regexes = append(l.regexes, regexes...)
+	return a
+}
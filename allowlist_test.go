@@ -0,0 +1,89 @@
+/*
+Anon (c) 2023 by Mikhail Kondrashin (mkondrashin@gmail.com)
+github.com/mpkondrashin/anon
+
+allowlist_test.go
+
+Allowlists testing functions
+*/
+package anon
+
+import (
+	"fmt"
+	"net/netip"
+	"regexp"
+	"testing"
+)
+
+func TestAllowlists(t *testing.T) {
+	a := New(Email, IP4, IP6, URL, DNSName).SetSalt([]byte{}).
+		Allow("0.0.0.0").
+		AllowValues(IP4, "127.0.0.1").
+		AllowNetworks(IP4, netip.MustParsePrefix("10.1.0.0/16")).
+		AllowNetworks(IP6, netip.MustParsePrefix("fd00::/8")).
+		AllowNetworks(URL, netip.MustParsePrefix("192.0.2.0/24")).
+		AllowDomains(Email, "Example.com").
+		AllowDomains(DNSName, "example.org.").
+		// Values found inside of allowed ones are checked by their own allowlists
+		AllowNetworks(IP4, netip.MustParsePrefix("192.0.2.0/24")).
+		AllowDomains(DNSName, "example.com", "other.com").
+		AllowDomains(URL, "example.org").
+		AllowRegex(Email, regexp.MustCompile(`^noreply@`))
+	type tCase struct {
+		input  string
+		hidden bool
+	}
+	tCases := []tCase{
+		{"0.0.0.0", false},
+		{"127.0.0.1", false},
+		{"127.0.0.2", true},
+		{"10.1.2.3", false},
+		{"10.2.2.3", true},
+		{"fd12::1", false},
+		{"2001:db8::1", true},
+		{"john@example.com", false},
+		{"john@mail.example.com", false},
+		{"john@notexample.com", true},
+		{"noreply@other.com", false},
+		{"www.example.org", false},
+		{"example.org", false},
+		{"www.other.org", true},
+		{"https://www.example.org/path", false},
+		{"https://192.0.2.10/path", false},
+		{"https://198.51.100.10/path", true},
+	}
+	for _, tc := range tCases {
+		t.Run(tc.input, func(t *testing.T) {
+			actual := a.Anonymize(tc.input)
+			if (actual != tc.input) != tc.hidden {
+				t.Errorf("%s: hidden expected %v, but got \"%s\"", tc.input, tc.hidden, actual)
+			}
+		})
+	}
+}
+
+func TestAllowlistOverlap(t *testing.T) {
+	// Data inside allowed URL must still be anonymized
+	a := New(Email, URL, IP4).SetSalt([]byte{}).AllowDomains(URL, "example.org")
+	input := "https://www.example.org/?email=john@secret.com&ip=8.8.8.8"
+	expected := "https://www.example.org/?email=" + a.Hide("john@secret.com") + "&ip=" + a.Hide("8.8.8.8")
+	if actual := a.Anonymize(input); actual != expected {
+		t.Errorf("expected \"%s\", but got \"%s\"", expected, actual)
+	}
+	// Domain of allowed email is checked by DNSName allowlist
+	a = New(Email, DNSName).AddDomains("com").AllowValues(Email, "john@example.com")
+	input = "mail john@example.com"
+	if findings := a.ScanValues(input); len(findings) != 1 || findings[0].Value != "example.com" {
+		t.Errorf("expected example.com, but got %v", findings)
+	}
+	a.AllowDomains(DNSName, "example.com")
+	if actual := a.Anonymize(input); actual != input {
+		t.Errorf("expected \"%s\", but got \"%s\"", input, actual)
+	}
+}
+
+func ExampleAnonymizer_AllowNetworks() {
+	a := New(IP4).SetSalt([]byte{}).AllowNetworks(IP4, netip.MustParsePrefix("10.0.0.0/8"))
+	fmt.Println(a.Anonymize("10.10.1.1 -> 1.1.1.1"))
+	// Output: 10.10.1.1 -> IP:G5yiayQG0JlmbYyijKDnosW2Q2d
+}
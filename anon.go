@@ -14,16 +14,23 @@ Go log anonymizer.
 // This value will be the same for all same values for this program run.
 // On the next run, this string of characters will be different, but for same values within this run, it will still be the same.
 // This property of obfuscated data gives ability to compare anonymized values.
+// With fixed salt and SetWindow values are the same within given time window (hour, day or week) only.
 package anon
 
 import (
+	"crypto/hmac"
+	"crypto/rand"
 	"crypto/sha1"
+	"crypto/sha256"
 	"encoding/base64"
 	"fmt"
 	"io"
-	"math/rand"
 	"regexp"
 	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"golang.org/x/exp/constraints"
@@ -31,39 +38,294 @@ import (
 
 // DataType - confidential data type
 
-//go:generate enum -package=anon -type=DataType -noprefix -values=Email,CreditCard,UUID3,UUID4,UUID5,UUID,Latitude,Longitude,IP4,IP6,DNSName,URL,SSN,IMEI,IMSI,E164
+//go:generate enum -package=anon -type=DataType -noprefix -values=Email,CreditCard,UUID3,UUID4,UUID5,UUID,Latitude,Longitude,IP4,IP6,DNSName,URL,SSN,IMEI,IMSI,E164,AWSAccessKey,AWSSecretKey,GitHubToken,GitLabToken,SlackToken,GoogleAPIKey,StripeKey,JWT,BearerToken,PrivateKey,HighEntropy,KeyValueSecret,IBAN,BIC,ABARouting,BitcoinAddress,EthereumAddress
 //go:generate go fmt enum_datatype.go
 
+// ParseDataType - return DataType for its name, e.g. "IP4".
+func ParseDataType(name string) (DataType, error) {
+	t, ok := mapDataTypeFromString[name]
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", ErrUnknownDataType, name)
+	}
+	return t, nil
+}
+
+// DataTypes - return list of all supported data types.
+func DataTypes() []DataType {
+	result := make([]DataType, 0, len(mapDataTypeFromString))
+	for _, t := range mapDataTypeFromString {
+		result = append(result, t)
+	}
+	sortSlice(result)
+	return result
+}
+
+// customPriority - priority of the first custom data type. Custom types always
+// have lower priority than built-in ones.
+const customPriority = 1 << 16
+
+// DefaultTokenLength - default length of tokens in characters.
+const DefaultTokenLength = 27
+
+// DefaultTemplate - default template of the replacement produced by HashToken strategy.
+const DefaultTemplate = "{prefix}:{token}"
+
 // Anonymizer - struct to anonymize text.
 type Anonymizer struct {
-	salt                 []byte
+	keys                 KeyProvider
+	window               Window
+	ipCipherCache        *atomic.Pointer[ipCipherEntry]
+	tokenCache           *atomic.Pointer[tokenEntry]
+	now                  func() time.Time
+	strategy             Strategy
+	tokenLength          int
+	legacyTokens         bool
+	template             string
+	allowAll             *allowlist
+	allowlists           map[DataType]*allowlist
+	ipPolicy             IPPolicy
+	maxLineLength        int
+	entropy              *entropyDetector
+	keyValue             *keyValueDetector
+	vault                *Vault
+	tenants              *sync.Map
 	confidentialDataList []confidentialData
 }
 
 // New - return new Anonymizer with random salt that will obfuscate automatically given list of types.
 func New(types ...DataType) *Anonymizer {
 	a := Anonymizer{
-		salt: randomSalt(),
+		now:         time.Now,
+		tenants:     &sync.Map{},
+		tokenCache:  &atomic.Pointer[tokenEntry]{},
+		strategy:    HashToken(),
+		tokenLength: DefaultTokenLength,
 	}
+	a.SetSalt(randomSalt())
 	sTypes := types[:]
 	sortSlice(sTypes)
 	for _, t := range sTypes {
-		a.confidentialDataList = append(a.confidentialDataList, confidentailData[t])
+		data := confidentailData[t]
+		data.dataType = t
+		data.priority = int(t)
+		a.confidentialDataList = append(a.confidentialDataList, data)
 	}
 	return &a
 }
 
-// SetSalt - set salt value instead of generated randomly.
+// detects - return true if built-in data type t is detected.
+func (a *Anonymizer) detects(t DataType) bool {
+	for i := range a.confidentialDataList {
+		if !a.confidentialDataList[i].isCustom() && a.confidentialDataList[i].dataType == t {
+			return true
+		}
+	}
+	return false
+}
+
+// SetTokenLength - set length of generated tokens in characters (not including key ID). Zero or value
+// exceeding maximum length (43 characters, 27 for legacy tokens) means maximum length.
+// Shorter tokens are more readable but increase the chance of collisions.
+func (a *Anonymizer) SetTokenLength(length int) *Anonymizer {
+	a.tokenLength = length
+	return a
+}
+
+// SetLegacyTokens - generate tokens as SHA-1 of salt and value like previous versions
+// of this package did instead of keyed HMAC-SHA256. Use it only to keep tokens
+// compatible with existing data, as such tokens for low entropy values (like IP addresses)
+// are easier to brute force.
+func (a *Anonymizer) SetLegacyTokens(legacy bool) *Anonymizer {
+	a.legacyTokens = legacy
+	return a
+}
+
+// SetTemplate - set template of the replacement produced by HashToken and Sequential strategies.
+// {prefix} is substituted by the data type prefix and {token} by the token, e.g. "<{prefix}-{token}>".
+// Default is DefaultTemplate.
+func (a *Anonymizer) SetTemplate(template string) *Anonymizer {
+	a.template = template
+	return a
+}
+
+// render - return replacement for prefix and token according to the template.
+func (a *Anonymizer) render(prefix, token string) string {
+	if a.template == "" || a.template == DefaultTemplate {
+		return prefix + ":" + token
+	}
+	return strings.NewReplacer("{prefix}", prefix, "{token}", token).Replace(a.template)
+}
+
+// tokenEntry - compiled regular expression of replacements for its source.
+type tokenEntry struct {
+	source string
+	rx     *regexp.Regexp
+}
+
+// tokenShape - return regular expression of tokens produced by strategy: numbers for
+// Sequential, key ID and encrypted value for Encryptor and hash tokens of configured
+// length with optional key ID otherwise.
+func (a *Anonymizer) tokenShape(s Strategy) string {
+	switch s.(type) {
+	case *sequential:
+		return `[0-9]+`
+	case *Encryptor:
+		return `[^\s.]+\.[A-Za-z0-9_-]{22,}`
+	}
+	length := base64.RawURLEncoding.EncodedLen(sha256.Size)
+	if a.legacyTokens {
+		length = base64.RawURLEncoding.EncodedLen(sha1.Size)
+	}
+	if a.tokenLength > 0 && a.tokenLength < length {
+		length = a.tokenLength
+	}
+	return `(?:[^\s.]+\.)?[A-Za-z0-9_-]{` + strconv.Itoa(length) + `}`
+}
+
+// replacements - return regular expression matching replacements produced by this
+// Anonymizer or nil if there is no way to tell them. Replacements of data types,
+// which template puts no text around the token, can not be told from other values.
+func (a *Anonymizer) replacements() *regexp.Regexp {
+	var forms []string
+	for i := range a.confidentialDataList {
+		data := &a.confidentialDataList[i]
+		head, tail, _ := strings.Cut(a.render(data.prefix, "\x00"), "\x00")
+		if head == "" && tail == "" {
+			continue
+		}
+		forms = append(forms, regexp.QuoteMeta(head)+`(?:`+a.tokenShape(a.strategyFor(data))+`)`+regexp.QuoteMeta(tail))
+	}
+	if len(forms) == 0 {
+		return nil
+	}
+	source := strings.Join(forms, "|")
+	if e := a.tokenCache.Load(); e != nil && e.source == source {
+		return e.rx
+	}
+	e := &tokenEntry{source: source, rx: regexp.MustCompile(source)}
+	a.tokenCache.Store(e)
+	return e.rx
+}
+
+// tokens - return locations of replacements produced by this Anonymizer in input.
+func (a *Anonymizer) tokens(input string) [][]int {
+	rx := a.replacements()
+	if rx == nil {
+		return nil
+	}
+	return rx.FindAllStringIndex(input, -1)
+}
+
+// isToken - return true if input[start:end] is a part of one of tokens,
+// so anonymized text is not anonymized again.
+func isToken(tokens [][]int, start, end int) bool {
+	for _, loc := range tokens {
+		if loc[0] <= start && end <= loc[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// SetVault - record tokens of all values replaced by HashToken strategy with original
+// values to the vault, so they can be revealed later. Values replaced by other strategies
+// are not recorded, as their replacements can not be revealed by the vault.
+func (a *Anonymizer) SetVault(vault *Vault) *Anonymizer {
+	a.vault = vault
+	return a
+}
+
+// SetSalt - set salt value instead of generated randomly. Salt is used as a key
+// for HMAC-SHA256, so it should be kept secret and be at least 32 bytes long.
 func (a *Anonymizer) SetSalt(salt []byte) *Anonymizer {
-	a.salt = salt
+	return a.SetKeyProvider(StaticKey("", salt))
+}
+
+// SetKeyProvider - use keys from provider instead of salt. If key has ID, tokens
+// include it followed by dot, like "IP:k1.qTQwwNaStHVodid1n8opcIH2xWo",
+// so it is known which key produced them.
+func (a *Anonymizer) SetKeyProvider(keys KeyProvider) *Anonymizer {
+	a.keys = keys
+	a.ipCipherCache = &atomic.Pointer[ipCipherEntry]{}
+	return a
+}
+
+// currentKey - return key to be used now.
+func (a *Anonymizer) currentKey() Key {
+	now := a.now()
+	return a.window.key(a.keys.Key(now), now)
+}
+
+// ipCipherEntry - Crypto-PAn anonymizer for the key.
+type ipCipherEntry struct {
+	key    string
+	cipher *cryptoPAn
+}
+
+// ipCipher - return Crypto-PAn anonymizer for the key. Only anonymizer for the
+// last used key is kept, as keys change rarely.
+func (a *Anonymizer) ipCipher(key Key) *cryptoPAn {
+	if e := a.ipCipherCache.Load(); e != nil && e.key == string(key.Value) {
+		return e.cipher
+	}
+	e := &ipCipherEntry{key: string(key.Value), cipher: newCryptoPAnFromSalt(key.Value)}
+	a.ipCipherCache.Store(e)
+	return e.cipher
+}
+
+// SetStrategy - set replacement strategy for given types. If no types are given,
+// strategy is used for all data types (including custom) that do not have their own strategy.
+// Nil strategy resets given types to the default one.
+func (a *Anonymizer) SetStrategy(strategy Strategy, types ...DataType) *Anonymizer {
+	if len(types) == 0 {
+		if strategy == nil {
+			strategy = HashToken()
+		}
+		a.strategy = strategy
+		return a
+	}
+	for i := range a.confidentialDataList {
+		data := &a.confidentialDataList[i]
+		if data.isCustom() {
+			continue
+		}
+		for _, t := range types {
+			if data.dataType == t {
+				data.strategy = strategy
+			}
+		}
+	}
+	return a
+}
+
+// SetCustomStrategy - set replacement strategy for already added custom data with given prefix.
+func (a *Anonymizer) SetCustomStrategy(strategy Strategy, prefix string) *Anonymizer {
+	for i := range a.confidentialDataList {
+		data := &a.confidentialDataList[i]
+		if data.isCustom() && data.prefix == prefix {
+			data.strategy = strategy
+		}
+	}
 	return a
 }
 
+// PreserveFormat - replace values of given types with values of the same format instead of tokens.
+// IP addresses are replaced with other valid addresses of the same family. Replacement is keyed by salt and
+// preserves prefixes: addresses sharing /24 network will still share /24 network
+// after anonymization. See FormatPreserving for details.
+func (a *Anonymizer) PreserveFormat(types ...DataType) *Anonymizer {
+	if len(types) == 0 {
+		return a
+	}
+	return a.SetStrategy(FormatPreserving(), types...)
+}
+
 // AddConfidentialData provides ability to extend list of types of anonymized data.
 func (a *Anonymizer) AddConfidentialData(prefix string, regex *regexp.Regexp, example string) *Anonymizer {
 	a.confidentialDataList = append(a.confidentialDataList, confidentialData{
-		prefix: prefix,
-		regex:  regex,
+		prefix:   prefix,
+		regex:    regex,
+		priority: customPriority + len(a.confidentialDataList),
 	})
 	return a
 }
@@ -71,7 +333,7 @@ func (a *Anonymizer) AddConfidentialData(prefix string, regex *regexp.Regexp, ex
 // AddDomains provides ability to anonymize DNS names for given top level domains.
 func (a *Anonymizer) AddDomains(tlds ...string) *Anonymizer {
 	for _, tld := range tlds {
-		a.AddConfidentialData("DNS", regexp.MustCompile(PatternDNSSubDomain+regexp.QuoteMeta(tld)), "")
+		a.AddConfidentialData("DNS", mustCompileLongest(PatternDNSSubDomain+regexp.QuoteMeta(tld)), "")
 	}
 	return a
 }
@@ -79,52 +341,84 @@ func (a *Anonymizer) AddDomains(tlds ...string) *Anonymizer {
 // Hide - anonymize given value. Prefix will be added automatically, if type will be detected.
 func (a *Anonymizer) Hide(v any) string {
 	s := fmt.Sprintf("%v", v)
-	h := a.hashAndEncode([]byte(s))
-	for _, each := range a.confidentialDataList {
-		if each.regex.Match([]byte(s)) {
-			return each.prefix + ":" + h
+	for i := range a.confidentialDataList {
+		data := &a.confidentialDataList[i]
+		if len(data.find(a, s)) > 0 {
+			return a.replace(data, s)
 		}
 	}
-	return h
+	return a.token(s)
 }
 
 // Anonymize - anonymyze confidential data found in string.
-func (a *Anonymizer) Anonymize(input string) (result string) {
-	result = input
-	for _, each := range a.confidentialDataList {
-		result = each.regex.ReplaceAllStringFunc(result, func(s string) string {
-			return each.prefix + ":" + a.hashAndEncode([]byte(s))
-		})
-	}
-	return
+// All of the types are searched in the original input in one pass, so
+// the result does not depend on the order of types and already replaced
+// values are never anonymized again.
+func (a *Anonymizer) Anonymize(input string) string {
+	return a.rewrite(input, a.scan(input))
 }
 
-// writer - io.writer comply struct that anonymezes all of the date written into it
-// before passing to the next io.writer.
-type writer struct {
-	anonymyzer *Anonymizer
-	target     io.Writer
+// replace - return anonymized value for data of given type.
+func (a *Anonymizer) replace(data *confidentialData, s string) string {
+	return a.strategyFor(data).Replace(a, data.prefix, s)
 }
 
-// Writer - return new io.Writer to anonymize data before writing to the target io.Writer.
-func (a *Anonymizer) Writer(target io.Writer) writer {
-	return writer{
-		anonymyzer: a,
-		target:     target,
+// strategyFor - return replacement strategy for data of given type.
+func (a *Anonymizer) strategyFor(data *confidentialData) Strategy {
+	if data.strategy != nil {
+		return data.strategy
 	}
+	return a.strategy
+}
+
+// Token - return token for given value. Token does not include data type prefix.
+func (a *Anonymizer) Token(value string) string {
+	return a.hashAndEncode([]byte(value))
 }
 
-// Write - anonymize data and write in to the target io.Writer
-func (w writer) Write(p []byte) (n int, err error) {
-	s := w.anonymyzer.Anonymize(string(p))
-	return w.target.Write([]byte(s))
+// token - return token for given value and record it to the vault if it is set.
+func (a *Anonymizer) token(value string) string {
+	t := a.Token(value)
+	if a.vault != nil {
+		a.vault.Record(t, value)
+	}
+	return t
 }
 
 func (a *Anonymizer) hashAndEncode(data []byte) string {
-	hasher := sha1.New()
-	hasher.Write(a.salt)
-	hasher.Write(data)
-	return base64.RawURLEncoding.EncodeToString(hasher.Sum(nil))
+	key := a.currentKey()
+	var sum []byte
+	if a.legacyTokens {
+		hasher := sha1.New()
+		hasher.Write(key.Value)
+		hasher.Write(data)
+		sum = hasher.Sum(nil)
+	} else {
+		mac := hmac.New(sha256.New, key.Value)
+		mac.Write(data)
+		sum = mac.Sum(nil)
+	}
+	token := base64.RawURLEncoding.EncodeToString(sum)
+	if a.tokenLength > 0 && a.tokenLength < len(token) {
+		token = token[:a.tokenLength]
+	}
+	if key.ID != "" {
+		token = key.ID + "." + token
+	}
+	return token
+}
+
+// TokenKeyID - return ID of the key that produced the token. Token can be given with
+// or without data type prefix. Empty string is returned for tokens without key ID.
+func TokenKeyID(token string) string {
+	if i := strings.LastIndexByte(token, ':'); i >= 0 {
+		token = token[i+1:]
+	}
+	id, _, found := strings.Cut(token, ".")
+	if !found {
+		return ""
+	}
+	return id
 }
 
 // defaultAnonymizer - anonymizer used for package global functions.
@@ -135,6 +429,21 @@ func SetSalt(salt []byte) {
 	defaultAnonymizer.SetSalt(salt)
 }
 
+// SetKeyProvider - use keys from provider instead of random default salt
+func SetKeyProvider(keys KeyProvider) {
+	defaultAnonymizer.SetKeyProvider(keys)
+}
+
+// SetWindow - make tokens stable only within given time window
+func SetWindow(window Window) {
+	defaultAnonymizer.SetWindow(window)
+}
+
+// SetIPPolicy - anonymize only given class of IP addresses
+func SetIPPolicy(policy IPPolicy) {
+	defaultAnonymizer.SetIPPolicy(policy)
+}
+
 // Hide - anonymize given value using default anonymizer.
 func Hide(v any) string {
 	return defaultAnonymizer.Hide(v)
@@ -145,22 +454,35 @@ func Anonymize(input string) string {
 	return defaultAnonymizer.Anonymize(input)
 }
 
-// Writer - return new io.Writer to anonymize all of the data before writing it to target
+// Writer - return new LineWriter to anonymize all of the data before writing it to target
 // using default anonymizer.
-func Writer(target io.Writer) writer {
+func Writer(target io.Writer) *LineWriter {
 	return defaultAnonymizer.Writer(target)
 }
 
+// Scan - return list of confidential data found in input using default anonymizer.
+func Scan(input string) []Finding {
+	return defaultAnonymizer.Scan(input)
+}
+
+// Reader - return new io.Reader that anonymizes all of the data read from source
+// using default anonymizer.
+func Reader(source io.Reader) io.Reader {
+	return defaultAnonymizer.Reader(source)
+}
+
 func sortSlice[T constraints.Ordered](s []T) {
 	sort.Slice(s, func(i, j int) bool {
 		return s[i] < s[j]
 	})
 }
 
+// randomSalt - return cryptographically secure random salt.
 func randomSalt() []byte {
-	seed := time.Now().UnixNano()
-	salt := make([]byte, 20)
-	rand.New(rand.NewSource(seed)).Read(salt)
+	salt := make([]byte, 32)
+	if _, err := rand.Read(salt); err != nil {
+		panic(fmt.Errorf("random salt: %w", err))
+	}
 	return salt
 }
 
@@ -41,31 +41,99 @@ func TestOrder(t *testing.T) {
 	input := "My email is michael@yahoo.com - please write me a letter"
 	a := New(DNSName, Email).SetSalt([]byte{})
 	output := a.Anonymize(input)
-	t.Log(input)
-	t.Log(output)
+	expected := "My email is Email:" + a.hashAndEncode([]byte("michael@yahoo.com")) + " - please write me a letter"
+	if output != expected {
+		t.Errorf("For \"%s\", expected \"%s\", but got \"%s\"", input, expected, output)
+	}
+}
+
+func TestSinglePass(t *testing.T) {
+	testCases := []struct {
+		name     string
+		types    []DataType
+		input    string
+		expected func(a *Anonymizer) string
+	}{
+		{"url with ip", []DataType{IP4, URL}, "see http://10.1.1.1/index.html now",
+			func(a *Anonymizer) string {
+				return "see URL:" + a.hashAndEncode([]byte("http://10.1.1.1/index.html")) + " now"
+			}},
+		{"ip and dns", []DataType{DNSName, IP4}, "10.1.1.1 and www.com",
+			func(a *Anonymizer) string {
+				return "IP:" + a.hashAndEncode([]byte("10.1.1.1")) + " and DNS:" + a.hashAndEncode([]byte("www.com"))
+			}},
+		{"no rescan", []DataType{DNSName, Email}, "a@b.com",
+			func(a *Anonymizer) string {
+				return "Email:" + a.hashAndEncode([]byte("a@b.com"))
+			}},
+	}
+	for _, tCase := range testCases {
+		t.Run(tCase.name, func(t *testing.T) {
+			a := New(tCase.types...).SetSalt([]byte{})
+			expected := tCase.expected(a)
+			for i := 0; i < 2; i++ {
+				actual := a.Anonymize(tCase.input)
+				if actual != expected {
+					t.Errorf("For \"%s\", expected \"%s\", but got \"%s\"", tCase.input, expected, actual)
+				}
+				// reversed order of types should not change the result
+				for l, r := 0, len(a.confidentialDataList)-1; l < r; l, r = l+1, r-1 {
+					a.confidentialDataList[l], a.confidentialDataList[r] = a.confidentialDataList[r], a.confidentialDataList[l]
+				}
+			}
+		})
+	}
 }
 
 func ExampleAnonymizer_Anonymize() {
 	a := New(IP4).AddDomains("local").SetSalt([]byte{})
 	fmt.Println(a.Anonymize("My address is 192.168.10.25 or tiger.local"))
-	// Output: My address is IP:EHbwEOPSp1Sh71NK9Das7K6u6N4 or DNS:Ebn_fbfAtZRMzYBMegpnLxUmNtQ
+	// Output: My address is IP:8-jLX0InL3RmizlBQL-8VTHbkQY or DNS:HqHrGxi5qnuePU0cUvXJytJhW1V
 }
 func ExampleAnonymizer_Hide_arbitrary() {
 	a := New(IP4).SetSalt([]byte{})
 	fmt.Println(a.Hide("My secret"))
-	// Output: vBnoJvUuJJbzaC4V2tHUZH4Kyxg
+	// Output: eLzhZ56U1nBGqIYc1cwLVYx6AkQ
 }
 func ExampleAnonymizer_Hide_ip() {
 	a := New(IP4).SetSalt([]byte{})
 	fmt.Println(a.Hide("10.10.1.1"))
+	// Output: IP:_M_jR4OvYA8NfVv3cruBTtC5U8R
+}
+
+func ExampleAnonymizer_SetLegacyTokens() {
+	a := New(IP4).SetSalt([]byte{}).SetLegacyTokens(true)
+	fmt.Println(a.Hide("10.10.1.1"))
 	// Output: IP:qTQwwNaStHVodid1n8opcIH2xWo
 }
 
-func ExampleAnonymizer_AddConfidentialDomain() {
+func ExampleAnonymizer_SetTokenLength() {
+	a := New(IP4).SetSalt([]byte{}).SetTokenLength(8)
+	fmt.Println(a.Hide("10.10.1.1"))
+	// Output: IP:_M_jR4Ov
+}
+
+func TestRandomSalt(t *testing.T) {
+	a, b := randomSalt(), randomSalt()
+	if len(a) != 32 {
+		t.Errorf("expected 32 bytes salt, but got %d", len(a))
+	}
+	if string(a) == string(b) {
+		t.Errorf("random salts are equal")
+	}
+}
+
+func ExampleAnonymizer_PreserveFormat() {
+	a := New(IP4).PreserveFormat(IP4).SetSalt([]byte{})
+	fmt.Println(a.Anonymize("Connection from 192.168.10.25 to 192.168.10.1"))
+	// Output: Connection from 131.183.213.249 to 131.183.213.230
+}
+
+func ExampleAnonymizer_AddDomains() {
 	a := New().AddDomains("in").SetSalt([]byte{})
 	fmt.Println(a.Anonymize("gopkg.in"))
 	fmt.Println(a.Anonymize("github.com"))
-	// Output: DNS:yyOdkTCd0TtQXCbZxAyZHO-Lc48
+	// Output: DNS:ovzvHWdMWsKGbmOdqrA_m9BfXcz
 	// github.com
 }
 
@@ -74,7 +142,7 @@ func ExampleAnonymizer_AddConfidentialData() {
 	hide := regexp.MustCompile(`hide\(.+\)`)
 	anonymizer.AddConfidentialData("hidden", hide, "")
 	fmt.Print(anonymizer.Anonymize("Please hide(the following)!"))
-	// Output: Please hidden:mGiY30dTiwzik-20Oi04XbZu7rA!
+	// Output: Please hidden:Vf33jTAeBcWYKp7y7vtowZ2E0lI!
 }
 
 func ExampleAnonymizer_Writer() {
@@ -83,19 +151,19 @@ func ExampleAnonymizer_Writer() {
 	log.SetOutput(writer)
 	log.SetFlags(0)
 	log.Print("My address is 10.10.1.1")
-	// Output: My address is IP:qTQwwNaStHVodid1n8opcIH2xWo
+	// Output: My address is IP:_M_jR4OvYA8NfVv3cruBTtC5U8R
 }
 
 func ExampleHide() {
 	SetSalt([]byte{})
 	fmt.Println(Hide("192.168.10.25"))
-	// Output: IP:EHbwEOPSp1Sh71NK9Das7K6u6N4
+	// Output: IP:8-jLX0InL3RmizlBQL-8VTHbkQY
 }
 
 func ExampleAnonymize() {
 	SetSalt([]byte{})
 	fmt.Println(Anonymize("My address is 192.168.10.25"))
-	// Output: My address is IP:EHbwEOPSp1Sh71NK9Das7K6u6N4
+	// Output: My address is IP:8-jLX0InL3RmizlBQL-8VTHbkQY
 }
 
 func ExampleWriter() {
@@ -104,7 +172,7 @@ func ExampleWriter() {
 	log.SetOutput(writer)
 	log.SetFlags(0)
 	log.Print("My address is 10.10.1.1")
-	// Output: My address is IP:qTQwwNaStHVodid1n8opcIH2xWo
+	// Output: My address is IP:_M_jR4OvYA8NfVv3cruBTtC5U8R
 }
 
 /*
@@ -0,0 +1,257 @@
+/*
+Anon (c) 2023 by Mikhail Kondrashin (mkondrashin@gmail.com)
+github.com/mpkondrashin/anon
+
+check.go
+
+Leak detection in files.
+*/
+package anon
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// binaryCheckSize - number of first bytes of the file checked for zero bytes
+// to detect binary files.
+const binaryCheckSize = 8000
+
+// Leak - confidential data found in a file. Start and End offsets
+// of the Finding are relative to the beginning of the line.
+type Leak struct {
+	Finding
+	// Path - name of the file.
+	Path string
+	// Line, Column and EndColumn - 1-based position of the value. Columns are counted
+	// in characters (Unicode code points). EndColumn points after the last character.
+	Line      int
+	Column    int
+	EndColumn int
+	// Snippet - the line containing the value with all of the found values masked.
+	Snippet string
+	// Fingerprint - stable identifier of the leak used by Baseline. It is HMAC of the rule,
+	// path relative to the checked directory and value keyed by the anonymizer key, so values
+	// can not be brute forced from the baseline. It does not depend on position of the value
+	// within file, but depends on the key: baseline can be reused only with the same salt.
+	Fingerprint string
+}
+
+// CheckFiles - scan given files and directories (recursively) for confidential data.
+// Binary files are skipped. Findings include values.
+func (a *Anonymizer) CheckFiles(paths ...string) ([]Leak, error) {
+	var result []Leak
+	for _, root := range paths {
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.Type().IsRegular() {
+				return nil
+			}
+			leaks, err := a.checkFile(path, relativePath(root, path))
+			if err != nil {
+				return err
+			}
+			result = append(result, leaks...)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// relativePath - return path relative to the root used for fingerprints, so they
+// do not depend on the way root is given. For file root its name is returned.
+func relativePath(root, path string) string {
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == "." {
+		return filepath.Base(path)
+	}
+	return rel
+}
+
+// checkFile - scan one file skipping it if it is binary.
+func (a *Anonymizer) checkFile(path, name string) ([]Leak, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	r := bufio.NewReaderSize(f, binaryCheckSize)
+	head, _ := r.Peek(binaryCheckSize)
+	if bytes.IndexByte(head, 0) >= 0 {
+		return nil, nil
+	}
+	return a.check(path, name, r)
+}
+
+// Check - scan data read from r line by line for confidential data.
+// Path is used only to fill Leak fields and fingerprints.
+func (a *Anonymizer) Check(path string, r io.Reader) ([]Leak, error) {
+	return a.check(path, path, r)
+}
+
+// check - scan data read from r. Name is the path used for fingerprints.
+func (a *Anonymizer) check(path, name string, r io.Reader) ([]Leak, error) {
+	key := a.keys.Key(a.now())
+	var result []Leak
+	reader := bufio.NewReader(r)
+	for lineNumber := 1; ; lineNumber++ {
+		line, err := reader.ReadString('\n')
+		findings := a.ScanValues(line)
+		snippet := maskedSnippet(line, findings)
+		for _, f := range findings {
+			column := utf8.RuneCountInString(line[:f.Start]) + 1
+			result = append(result, Leak{
+				Finding:     f,
+				Path:        path,
+				Line:        lineNumber,
+				Column:      column,
+				EndColumn:   column + utf8.RuneCountInString(f.Value),
+				Snippet:     snippet,
+				Fingerprint: fingerprint(key, name, f),
+			})
+		}
+		if errors.Is(err, io.EOF) {
+			return result, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// maskedSnippet - return line with all of the findings masked.
+func maskedSnippet(line string, findings []Finding) string {
+	if len(findings) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	last := 0
+	for _, f := range findings {
+		sb.WriteString(line[last:f.Start])
+		sb.WriteString(maskValue(line[f.Start:f.End]))
+		last = f.End
+	}
+	sb.WriteString(line[last:])
+	return strings.TrimRight(sb.String(), "\r\n")
+}
+
+// maskValue - replace all characters of the value except the first and the last
+// ones with asterisks. Short values are masked completely.
+func maskValue(value string) string {
+	runes := []rune(value)
+	if len(runes) <= 4 {
+		return strings.Repeat("*", len(runes))
+	}
+	return string(runes[0]) + strings.Repeat("*", len(runes)-2) + string(runes[len(runes)-1])
+}
+
+// fingerprint - return stable identifier of the finding in the file keyed by key.
+func fingerprint(key Key, path string, f Finding) string {
+	h := hmac.New(sha256.New, key.Value)
+	for _, s := range []string{"anon fingerprint", f.Rule(), filepath.ToSlash(filepath.Clean(path)), f.Value} {
+		h.Write([]byte(s))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// BaselineEntry - accepted leak.
+type BaselineEntry struct {
+	Fingerprint string `json:"fingerprint"`
+	Rule        string `json:"rule"`
+	Path        string `json:"path"`
+}
+
+// Baseline - list of accepted leaks that should not fail the check. It is stored
+// as JSON and keeps only fingerprints, rules and paths, but not the values.
+type Baseline struct {
+	Entries []BaselineEntry `json:"entries"`
+}
+
+// NewBaseline - return baseline accepting all of the given leaks.
+func NewBaseline(leaks []Leak) *Baseline {
+	b := &Baseline{Entries: []BaselineEntry{}}
+	seen := make(map[string]struct{})
+	for _, leak := range leaks {
+		if _, ok := seen[leak.Fingerprint]; ok {
+			continue
+		}
+		seen[leak.Fingerprint] = struct{}{}
+		b.Entries = append(b.Entries, BaselineEntry{
+			Fingerprint: leak.Fingerprint,
+			Rule:        leak.Rule(),
+			Path:        filepath.ToSlash(leak.Path),
+		})
+	}
+	return b
+}
+
+// ReadBaseline - read baseline from JSON.
+func ReadBaseline(r io.Reader) (*Baseline, error) {
+	var b Baseline
+	if err := json.NewDecoder(r).Decode(&b); err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// ReadBaselineFile - read baseline from JSON file.
+func ReadBaselineFile(path string) (*Baseline, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadBaseline(f)
+}
+
+// Write - write baseline as JSON.
+func (b *Baseline) Write(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(b)
+}
+
+// WriteFile - write baseline to JSON file.
+func (b *Baseline) WriteFile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := b.Write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// New - return leaks that are not accepted by baseline.
+func (b *Baseline) New(leaks []Leak) []Leak {
+	accepted := make(map[string]struct{}, len(b.Entries))
+	for _, entry := range b.Entries {
+		accepted[entry.Fingerprint] = struct{}{}
+	}
+	var result []Leak
+	for _, leak := range leaks {
+		if _, ok := accepted[leak.Fingerprint]; !ok {
+			result = append(result, leak)
+		}
+	}
+	return result
+}
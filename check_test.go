@@ -0,0 +1,92 @@
+/*
+Anon (c) 2023 by Mikhail Kondrashin (mkondrashin@gmail.com)
+github.com/mpkondrashin/anon
+
+check_test.go
+
+Leak detection testing functions
+*/
+package anon
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckFiles(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"a.log":       "first line\nmail to a@b.com and c@d.com\n",
+		"sub/b.log":   "from 10.10.1.1",
+		"binary.data": "\x00a@b.com",
+		"late.data":   "a@b.com\n" + strings.Repeat("x", 5000) + "\x00",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0700)
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	a := New(Email, IP4).SetSalt([]byte("salt"))
+	leaks, err := a.CheckFiles(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []struct {
+		path   string
+		line   int
+		column int
+		value  string
+	}{
+		{"a.log", 2, 9, "a@b.com"},
+		{"a.log", 2, 21, "c@d.com"},
+		{"sub/b.log", 1, 6, "10.10.1.1"},
+	}
+	if len(leaks) != len(expected) {
+		t.Fatalf("expected %d leaks, but got %d: %v", len(expected), len(leaks), leaks)
+	}
+	for i, e := range expected {
+		l := leaks[i]
+		if l.Path != filepath.Join(dir, e.path) || l.Line != e.line || l.Column != e.column || l.Value != e.value {
+			t.Errorf("expected %v, but got %v", e, l)
+		}
+	}
+	if leaks[0].Fingerprint == leaks[1].Fingerprint {
+		t.Errorf("different values have same fingerprint")
+	}
+	again, _ := New(Email, IP4).SetSalt([]byte("salt")).CheckFiles(filepath.Join(dir, "..", filepath.Base(dir)))
+	if again[0].Fingerprint != leaks[0].Fingerprint {
+		t.Errorf("fingerprint depends on the way directory is given")
+	}
+	other, _ := New(Email, IP4).SetSalt([]byte("other")).CheckFiles(dir)
+	if other[0].Fingerprint == leaks[0].Fingerprint {
+		t.Errorf("fingerprint does not depend on salt")
+	}
+}
+
+func TestBaseline(t *testing.T) {
+	a := New(Email)
+	leaks, err := a.Check("a.log", bytes.NewBufferString("a@b.com\nc@d.com\na@b.com\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := NewBaseline(leaks[:1]).Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	baseline, err := ReadBaseline(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newLeaks := baseline.New(leaks)
+	if len(newLeaks) != 1 || newLeaks[0].Value != "c@d.com" {
+		t.Errorf("expected only c@d.com to be new, but got %v", newLeaks)
+	}
+	if len(baseline.Entries) != 1 || baseline.Entries[0].Rule != "Email" {
+		t.Errorf("unexpected baseline %v", baseline)
+	}
+}
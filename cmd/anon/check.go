@@ -0,0 +1,101 @@
+/*
+Anon (c) 2023 by Mikhail Kondrashin (mkondrashin@gmail.com)
+github.com/mpkondrashin/anon
+
+cmd/anon/check.go
+
+Leak detection command.
+*/
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/mpkondrashin/anon"
+)
+
+// errLeaks - returned wrapped when new leaks are found.
+var errLeaks = errors.New("new leaks found")
+
+// reports - leak report writers by format name.
+var reports = map[string]func(io.Writer, []anon.Leak) error{
+	"text":  writeText,
+	"json":  anon.WriteJSONLines,
+	"sarif": anon.WriteSARIF,
+}
+
+// writeText - write leaks in compiler-like format: path:line:column: rule: snippet.
+func writeText(w io.Writer, leaks []anon.Leak) error {
+	for _, leak := range leaks {
+		_, err := fmt.Fprintf(w, "%s:%d:%d: %s: %s\n", leak.Path, leak.Line, leak.Column, leak.Rule(), leak.Snippet)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runCheck - scan files and directories for confidential data.
+func runCheck(args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("anon check", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: anon check [options] path ...\n\n"+
+			"Report confidential data found in files and directories. Exit with non-zero code if\n"+
+			"there are findings not accepted by baseline.\n\nOptions:\n")
+		fs.PrintDefaults()
+	}
+	var o options
+	o.register(fs)
+	baseline := fs.String("baseline", "", "JSON file with accepted findings")
+	update := fs.Bool("update-baseline", false, "write all findings to baseline file instead of reporting them")
+	format := fs.String("format", "text", "report format: text, json (JSON lines) or sarif")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return errors.New("no paths to check")
+	}
+	report, ok := reports[*format]
+	if !ok {
+		return fmt.Errorf("unknown format %q", *format)
+	}
+	if *update && *baseline == "" {
+		return errors.New("-update-baseline requires -baseline")
+	}
+	if *baseline != "" && o.config == "" {
+		// Fingerprints are keyed by salt, so random one would never match the baseline
+		if salt, err := o.readSalt(); err == nil && salt == nil {
+			return errors.New("-baseline requires fixed salt: use -salt, -salt-file or " + saltEnv)
+		}
+	}
+	a, err := o.anonymizer()
+	if err != nil {
+		return err
+	}
+	leaks, err := a.CheckFiles(fs.Args()...)
+	if err != nil {
+		return err
+	}
+	if *update {
+		return anon.NewBaseline(leaks).WriteFile(*baseline)
+	}
+	if *baseline != "" {
+		b, err := anon.ReadBaselineFile(*baseline)
+		if err != nil {
+			return fmt.Errorf("baseline: %w", err)
+		}
+		leaks = b.New(leaks)
+	}
+	if err := report(stdout, leaks); err != nil {
+		return err
+	}
+	if len(leaks) > 0 {
+		return fmt.Errorf("%d %w", len(leaks), errLeaks)
+	}
+	return nil
+}
@@ -0,0 +1,294 @@
+/*
+Anon (c) 2023 by Mikhail Kondrashin (mkondrashin@gmail.com)
+github.com/mpkondrashin/anon
+
+cmd/anon/main.go
+
+Command line tool to anonymize files and pipes.
+*/
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/mpkondrashin/anon"
+)
+
+// saltEnv - environment variable to get salt from.
+const saltEnv = "ANON_SALT"
+
+// vaultKeyEnv - environment variable to get hex encoded vault key from.
+const vaultKeyEnv = "ANON_VAULT_KEY"
+
+// defaultTypes - types anonymized if none are given explicitly.
+const defaultTypes = "Email,CreditCard,IP4,IP6,URL"
+
+// listFlag - flag that can be given several times.
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *listFlag) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+func main() {
+	if err := run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr); err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintf(os.Stderr, "anon: %v\n", err)
+		}
+		os.Exit(exitCode(err))
+	}
+}
+
+// exitCode - return process exit code for the error.
+func exitCode(err error) int {
+	if errors.Is(err, flag.ErrHelp) {
+		return 2
+	}
+	return 1
+}
+
+// run - execute command with given arguments.
+// Commands other than anonymization are chosen by the first argument.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	if len(args) > 0 {
+		switch args[0] {
+		case "check":
+			return runCheck(args[1:], stdout, stderr)
+		case "reveal":
+			return runReveal(args[1:], stdout, stderr)
+		}
+	}
+	return runAnonymize(args, stdin, stdout, stderr)
+}
+
+// options - parameters to build anonymizer.
+type options struct {
+	config   string
+	types    string
+	salt     string
+	saltFile string
+	window   string
+	ipPolicy string
+	rules    listFlag
+	domains  listFlag
+}
+
+// register - add anonymizer options to the flag set.
+func (o *options) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.config, "config", "", "YAML or JSON configuration file (-types is ignored if given)")
+	fs.StringVar(&o.types, "types", defaultTypes, "comma separated list of data types to anonymize ("+typeNames()+")")
+	fs.StringVar(&o.salt, "salt", "", "salt value (default: "+saltEnv+" environment variable or random)")
+	fs.StringVar(&o.saltFile, "salt-file", "", "file to read salt from")
+	fs.StringVar(&o.window, "window", "", "time window tokens are stable within (none, hour, day or week)")
+	fs.StringVar(&o.ipPolicy, "ip-policy", "", "IP addresses to anonymize (all, public, private or non-local)")
+	fs.Var(&o.rules, "rule", "custom rule in form prefix=regex (can be repeated)")
+	fs.Var(&o.domains, "domains", "comma separated list of domains to anonymize DNS names for (can be repeated)")
+}
+
+// anonymizer - return anonymizer configured by options.
+func (o *options) anonymizer() (*anon.Anonymizer, error) {
+	a, err := o.base()
+	if err != nil {
+		return nil, err
+	}
+	salt, err := o.readSalt()
+	if err != nil {
+		return nil, err
+	}
+	if salt != nil {
+		a.SetSalt(salt)
+	}
+	if o.window != "" {
+		window, err := anon.ParseWindow(o.window)
+		if err != nil {
+			return nil, err
+		}
+		a.SetWindow(window)
+	}
+	if o.ipPolicy != "" {
+		policy, err := anon.ParseIPPolicy(o.ipPolicy)
+		if err != nil {
+			return nil, err
+		}
+		a.SetIPPolicy(policy)
+	}
+	for _, rule := range o.rules {
+		prefix, expr, found := strings.Cut(rule, "=")
+		if !found || prefix == "" {
+			return nil, fmt.Errorf("rule %q: expected prefix=regex", rule)
+		}
+		regex, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("rule %q: %w", rule, err)
+		}
+		a.AddConfidentialData(prefix, regex, "")
+	}
+	for _, domains := range o.domains {
+		a.AddDomains(splitList(domains)...)
+	}
+	return a, nil
+}
+
+// base - return anonymizer from configuration file or for the list of types.
+func (o *options) base() (*anon.Anonymizer, error) {
+	if o.config != "" {
+		return anon.LoadConfigFile(o.config)
+	}
+	var types []anon.DataType
+	for _, name := range splitList(o.types) {
+		t, err := anon.ParseDataType(name)
+		if err != nil {
+			return nil, err
+		}
+		types = append(types, t)
+	}
+	return anon.New(types...), nil
+}
+
+// readSalt - return salt from flag, file or environment. Environment is not used if
+// configuration file is given. Nil means salt should not be changed.
+func (o *options) readSalt() ([]byte, error) {
+	if o.salt != "" && o.saltFile != "" {
+		return nil, errors.New("both -salt and -salt-file are given")
+	}
+	if o.salt != "" {
+		return []byte(o.salt), nil
+	}
+	if o.saltFile != "" {
+		data, err := os.ReadFile(o.saltFile)
+		if err != nil {
+			return nil, fmt.Errorf("salt file: %w", err)
+		}
+		return []byte(strings.TrimRight(string(data), "\r\n")), nil
+	}
+	if o.config != "" {
+		return nil, nil
+	}
+	if salt, ok := os.LookupEnv(saltEnv); ok {
+		return []byte(salt), nil
+	}
+	return nil, nil
+}
+
+// runAnonymize - anonymize files or stdin.
+func runAnonymize(args []string, stdin io.Reader, stdout, stderr io.Writer) (returnErr error) {
+	fs := flag.NewFlagSet("anon", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: anon [options] [file ...]\n       anon check [options] path ...\n"+
+			"       anon reveal [options] token ...\n\n"+
+			"Anonymize files (or stdin) and write result to stdout.\n\nOptions:\n")
+		fs.PrintDefaults()
+	}
+	var o options
+	o.register(fs)
+	var v vaultOptions
+	v.register(fs)
+	inPlace := fs.Bool("in-place", false, "rewrite files instead of writing to stdout")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	a, err := o.anonymizer()
+	if err != nil {
+		return err
+	}
+	if v.path != "" {
+		vault, err := v.open()
+		if err != nil {
+			return err
+		}
+		a.SetVault(vault)
+		defer func() {
+			if err := vault.Close(); err != nil && returnErr == nil {
+				returnErr = err
+			}
+		}()
+	}
+	if fs.NArg() == 0 {
+		if *inPlace {
+			return errors.New("-in-place requires files")
+		}
+		_, err := io.Copy(stdout, a.Reader(stdin))
+		return err
+	}
+	for _, path := range fs.Args() {
+		if *inPlace {
+			err = anonymizeInPlace(a, path)
+		} else {
+			err = anonymizeFile(a, path, stdout)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// anonymizeFile - write anonymized content of the file to w.
+func anonymizeFile(a *anon.Anonymizer, path string, w io.Writer) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if _, err := io.Copy(w, a.Reader(f)); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// anonymizeInPlace - replace file with its anonymized version.
+func anonymizeInPlace(a *anon.Anonymizer, path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := anonymizeFile(a, path, tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(info.Mode()); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// splitList - split comma separated list ignoring empty items.
+func splitList(s string) (result []string) {
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return
+}
+
+// typeNames - return comma separated list of supported data types.
+func typeNames() string {
+	var names []string
+	for _, t := range anon.DataTypes() {
+		names = append(names, t.String())
+	}
+	return strings.Join(names, ", ")
+}
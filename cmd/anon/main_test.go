@@ -0,0 +1,178 @@
+/*
+Anon (c) 2023 by Mikhail Kondrashin (mkondrashin@gmail.com)
+github.com/mpkondrashin/anon
+
+cmd/anon/main_test.go
+
+Command line tool testing functions
+*/
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mpkondrashin/anon"
+)
+
+func TestRunStdin(t *testing.T) {
+	var stdout, stderr strings.Builder
+	input := "host 10.10.1.1 mail john@example.com id=ABC-123 at db.corp"
+	err := run([]string{"-types", "IP4", "-salt", "s", "-rule", "ID=ABC-\\d+", "-domains", "corp"},
+		strings.NewReader(input), &stdout, &stderr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := anon.New(anon.IP4).SetSalt([]byte("s"))
+	expected := "host IP:" + a.Token("10.10.1.1") + " mail john@example.com id=ID:" + a.Token("ABC-123") +
+		" at DNS:" + a.Token("db.corp")
+	if stdout.String() != expected {
+		t.Errorf("expected \"%s\", but got \"%s\"", expected, stdout.String())
+	}
+}
+
+func TestRunInPlace(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "log.txt")
+	if err := os.WriteFile(path, []byte("host 10.10.1.1\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	saltPath := filepath.Join(dir, "salt")
+	if err := os.WriteFile(saltPath, []byte("s\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	var stdout, stderr strings.Builder
+	if err := run([]string{"-in-place", "-salt-file", saltPath, path}, nil, &stdout, &stderr); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "host IP:" + anon.New(anon.IP4).SetSalt([]byte("s")).Token("10.10.1.1") + "\n"
+	if string(data) != expected {
+		t.Errorf("expected \"%s\", but got \"%s\"", expected, string(data))
+	}
+}
+
+func TestRunConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "anon.yaml")
+	if err := os.WriteFile(path, []byte("types: [Email]\nsalt: {value: s}\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	var stdout, stderr strings.Builder
+	if err := run([]string{"-config", path}, strings.NewReader("a@b.com 10.10.1.1"), &stdout, &stderr); err != nil {
+		t.Fatal(err)
+	}
+	expected := "Email:" + anon.New().SetSalt([]byte("s")).Token("a@b.com") + " 10.10.1.1"
+	if stdout.String() != expected {
+		t.Errorf("expected \"%s\", but got \"%s\"", expected, stdout.String())
+	}
+}
+
+func TestRunCheck(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "test.log")
+	if err := os.WriteFile(path, []byte("ok\nuser a@b.com\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	var stdout, stderr strings.Builder
+	err := run([]string{"check", dir}, nil, &stdout, &stderr)
+	if !errors.Is(err, errLeaks) {
+		t.Fatalf("expected errLeaks, but got %v", err)
+	}
+	expected := path + ":2:6: Email: user a*****m\n"
+	if stdout.String() != expected {
+		t.Errorf("expected \"%s\", but got \"%s\"", expected, stdout.String())
+	}
+	baseline := filepath.Join(t.TempDir(), "baseline.json")
+	if err := run([]string{"check", "-salt", "s", "-baseline", baseline, "-update-baseline", dir}, nil, &stdout, &stderr); err != nil {
+		t.Fatal(err)
+	}
+	stdout.Reset()
+	if err := run([]string{"check", "-salt", "s", "-baseline", baseline, dir}, nil, &stdout, &stderr); err != nil {
+		t.Fatal(err)
+	}
+	if stdout.Len() != 0 {
+		t.Errorf("expected no output, but got \"%s\"", stdout.String())
+	}
+	stdout.Reset()
+	err = run([]string{"check", "-format", "json", dir}, nil, &stdout, &stderr)
+	if !errors.Is(err, errLeaks) {
+		t.Fatalf("expected errLeaks, but got %v", err)
+	}
+	if !strings.Contains(stdout.String(), `"rule":"Email"`) {
+		t.Errorf("unexpected JSON report \"%s\"", stdout.String())
+	}
+}
+
+func TestRunReveal(t *testing.T) {
+	dir := t.TempDir()
+	vault := filepath.Join(dir, "vault")
+	keyFile := filepath.Join(dir, "key")
+	if err := os.WriteFile(keyFile, []byte(strings.Repeat("ab", 32)+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	var stdout, stderr strings.Builder
+	err := run([]string{"-types", "IP4", "-salt", "s", "-vault", vault, "-vault-key-file", keyFile},
+		strings.NewReader("from 10.10.1.1"), &stdout, &stderr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token := strings.TrimPrefix(stdout.String(), "from ")
+	stdout.Reset()
+	if err := run([]string{"reveal", "-vault", vault, "-vault-key-file", keyFile, token}, nil, &stdout, &stderr); err != nil {
+		t.Fatal(err)
+	}
+	expected := token + "\t10.10.1.1\n"
+	if stdout.String() != expected {
+		t.Errorf("expected \"%s\", but got \"%s\"", expected, stdout.String())
+	}
+	t.Setenv(vaultKeyEnv, strings.Repeat("cd", 32))
+	err = run([]string{"reveal", "-vault", vault, token}, nil, &stdout, &stderr)
+	if !errors.Is(err, anon.ErrVaultKey) {
+		t.Errorf("expected ErrVaultKey, but got %v", err)
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	tCases := []struct {
+		name string
+		args []string
+	}{
+		{"unknown type", []string{"-types", "Phone"}},
+		{"bad rule", []string{"-rule", "noequal"}},
+		{"bad regex", []string{"-rule", "X=("}},
+		{"missing file", []string{"-salt", "s", "/nonexistent/file"}},
+		{"in place stdin", []string{"-in-place"}},
+		{"two salts", []string{"-salt", "a", "-salt-file", "b"}},
+		{"unknown window", []string{"-window", "month"}},
+		{"unknown IP policy", []string{"-ip-policy", "some"}},
+		{"missing config", []string{"-config", "/nonexistent/config"}},
+		{"check no paths", []string{"check"}},
+		{"check missing path", []string{"check", "/nonexistent/path"}},
+		{"check update without baseline", []string{"check", "-update-baseline", "."}},
+		{"check unknown format", []string{"check", "-format", "xml", "."}},
+		{"check baseline without salt", []string{"check", "-baseline", "b.json", "."}},
+		{"reveal without vault", []string{"reveal", "token"}},
+		{"reveal without tokens", []string{"reveal", "-vault", "v"}},
+		{"vault without key", []string{"-vault", "/nonexistent/vault"}},
+	}
+	for _, tCase := range tCases {
+		t.Run(tCase.name, func(t *testing.T) {
+			var stdout, stderr strings.Builder
+			err := run(tCase.args, strings.NewReader(""), &stdout, &stderr)
+			if err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+	var stderr strings.Builder
+	err := run([]string{"-types", "Phone"}, nil, &stderr, &stderr)
+	if !errors.Is(err, anon.ErrUnknownDataType) {
+		t.Errorf("expected ErrUnknownDataType, but got %v", err)
+	}
+}
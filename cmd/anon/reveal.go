@@ -0,0 +1,101 @@
+/*
+Anon (c) 2023 by Mikhail Kondrashin (mkondrashin@gmail.com)
+github.com/mpkondrashin/anon
+
+cmd/anon/reveal.go
+
+Reveal original values of tokens using vault.
+*/
+package main
+
+import (
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/mpkondrashin/anon"
+)
+
+// vaultOptions - parameters to open vault.
+type vaultOptions struct {
+	path    string
+	keyFile string
+}
+
+// register - add vault options to the flag set.
+func (v *vaultOptions) register(fs *flag.FlagSet) {
+	fs.StringVar(&v.path, "vault", "", "vault file to record (or reveal) original values of tokens")
+	fs.StringVar(&v.keyFile, "vault-key-file", "", "file with hex encoded vault key (default: "+vaultKeyEnv+" environment variable)")
+}
+
+// open - open vault with key from file or environment.
+func (v *vaultOptions) open() (*anon.Vault, error) {
+	key, err := v.key()
+	if err != nil {
+		return nil, err
+	}
+	return anon.OpenVault(v.path, key)
+}
+
+// key - return vault key.
+func (v *vaultOptions) key() ([]byte, error) {
+	var encoded string
+	if v.keyFile != "" {
+		data, err := os.ReadFile(v.keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("vault key: %w", err)
+		}
+		encoded = string(data)
+	} else {
+		var ok bool
+		encoded, ok = os.LookupEnv(vaultKeyEnv)
+		if !ok {
+			return nil, errors.New("vault key is required: use -vault-key-file or " + vaultKeyEnv)
+		}
+	}
+	key, err := hex.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("vault key: %w", err)
+	}
+	return key, nil
+}
+
+// runReveal - print original values for tokens.
+func runReveal(args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("anon reveal", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: anon reveal [options] token ...\n\n"+
+			"Print original values of the tokens recorded to the vault.\n\nOptions:\n")
+		fs.PrintDefaults()
+	}
+	var v vaultOptions
+	v.register(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if v.path == "" {
+		return errors.New("-vault is required")
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return errors.New("no tokens to reveal")
+	}
+	vault, err := v.open()
+	if err != nil {
+		return err
+	}
+	defer vault.Close()
+	for _, token := range fs.Args() {
+		value, err := vault.Reveal(token)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "%s\t%s\n", token, value)
+	}
+	return nil
+}
@@ -0,0 +1,412 @@
+/*
+Anon (c) 2023 by Mikhail Kondrashin (mkondrashin@gmail.com)
+github.com/mpkondrashin/anon
+
+config.go
+
+Declarative configuration of the Anonymizer.
+*/
+package anon
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ErrConfig - will be returned wrapped for invalid configuration.
+var ErrConfig = errors.New("invalid config")
+
+// Config - declarative description of the Anonymizer. It can be loaded from YAML
+// or JSON file using LoadConfig. Example:
+//
+//	types: [Email, CreditCard, IP4]
+//	patterns:
+//	  - prefix: ID
+//	    regex: 'ID-\d+'
+//	    example: ID-123
+//	domains: [corp, local]
+//	allow: [127.0.0.1]
+//	allowlists:
+//	  IP4: {networks: [10.0.0.0/8]}
+//	  Email: {domains: [example.com]}
+//	strategies:
+//	  CreditCard: {name: mask, keep: 4}
+//	salt:
+//	  env: ANON_SALT
+//	window: day
+//	ip_policy: public
+//	entropy: {min_length: 24, keywords: [secret, token]}
+//	secret_keys: [password, token, api_key]
+//	template: "{prefix}:{token}"
+type Config struct {
+	Types        []string                  `yaml:"types" json:"types"`
+	Patterns     []PatternConfig           `yaml:"patterns" json:"patterns"`
+	Domains      []string                  `yaml:"domains" json:"domains"`
+	Allow        []string                  `yaml:"allow" json:"allow"`
+	Allowlists   map[string]AllowConfig    `yaml:"allowlists" json:"allowlists"`
+	Strategy     *StrategyConfig           `yaml:"strategy" json:"strategy"`
+	Strategies   map[string]StrategyConfig `yaml:"strategies" json:"strategies"`
+	Salt         SaltConfig                `yaml:"salt" json:"salt"`
+	Window       string                    `yaml:"window" json:"window"`
+	IPPolicy     string                    `yaml:"ip_policy" json:"ip_policy"`
+	Entropy      *EntropyConfig            `yaml:"entropy" json:"entropy"`
+	SecretKeys   []string                  `yaml:"secret_keys" json:"secret_keys"`
+	Template     string                    `yaml:"template" json:"template"`
+	TokenLength  int                       `yaml:"token_length" json:"token_length"`
+	LegacyTokens bool                      `yaml:"legacy_tokens" json:"legacy_tokens"`
+}
+
+// PatternConfig - custom confidential data type. If example is given, it should match the regex.
+type PatternConfig struct {
+	Prefix   string          `yaml:"prefix" json:"prefix"`
+	Regex    string          `yaml:"regex" json:"regex"`
+	Example  string          `yaml:"example" json:"example"`
+	Strategy *StrategyConfig `yaml:"strategy" json:"strategy"`
+}
+
+// AllowConfig - values of the data type that must never be anonymized: exact values,
+// networks in CIDR notation, domains (including subdomains) and regexes.
+type AllowConfig struct {
+	Values   []string `yaml:"values" json:"values"`
+	Networks []string `yaml:"networks" json:"networks"`
+	Domains  []string `yaml:"domains" json:"domains"`
+	Regexes  []string `yaml:"regexes" json:"regexes"`
+}
+
+// EntropyConfig - options of the HighEntropy detector (see EntropyOptions).
+// Benign is a list of regexes replacing DefaultEntropyBenign if given, empty list
+// disables it.
+type EntropyConfig struct {
+	MinLength        int      `yaml:"min_length" json:"min_length"`
+	Base64Threshold  float64  `yaml:"base64_threshold" json:"base64_threshold"`
+	HexThreshold     float64  `yaml:"hex_threshold" json:"hex_threshold"`
+	Keywords         []string `yaml:"keywords" json:"keywords"`
+	KeywordMinLength int      `yaml:"keyword_min_length" json:"keyword_min_length"`
+	KeywordThreshold float64  `yaml:"keyword_threshold" json:"keyword_threshold"`
+	Benign           []string `yaml:"benign" json:"benign"`
+}
+
+// StrategyConfig - replacement strategy. Name is one of hash, redact (uses text),
+// mask (uses keep and mask), truncate (uses length), format or sequential.
+type StrategyConfig struct {
+	Name   string `yaml:"name" json:"name"`
+	Text   string `yaml:"text" json:"text"`
+	Keep   int    `yaml:"keep" json:"keep"`
+	Mask   string `yaml:"mask" json:"mask"`
+	Length int    `yaml:"length" json:"length"`
+}
+
+// SaltConfig - source of the salt. Only one of the fields can be set.
+// If none is set, random salt is used.
+type SaltConfig struct {
+	Value string `yaml:"value" json:"value"`
+	File  string `yaml:"file" json:"file"`
+	Env   string `yaml:"env" json:"env"`
+}
+
+// LoadConfig - read YAML or JSON configuration and return ready to use Anonymizer.
+func LoadConfig(r io.Reader) (*Anonymizer, error) {
+	c, err := ReadConfig(r)
+	if err != nil {
+		return nil, err
+	}
+	return c.Anonymizer()
+}
+
+// LoadConfigFile - read configuration file and return ready to use Anonymizer.
+func LoadConfigFile(path string) (*Anonymizer, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	a, err := LoadConfig(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return a, nil
+}
+
+// ReadConfig - read YAML or JSON configuration. Unknown fields are reported as errors.
+func ReadConfig(r io.Reader) (*Config, error) {
+	var c Config
+	decoder := yaml.NewDecoder(r)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&c); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%w: %v", ErrConfig, err)
+	}
+	return &c, nil
+}
+
+// Anonymizer - return Anonymizer built according to configuration. All of the
+// configuration problems are reported at once.
+func (c *Config) Anonymizer() (*Anonymizer, error) {
+	var errs []error
+	report := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf("%w: "+format, append([]any{ErrConfig}, args...)...))
+	}
+	var types []DataType
+	for i, name := range c.Types {
+		t, err := ParseDataType(name)
+		if err != nil {
+			report("types[%d]: %v", i, err)
+			continue
+		}
+		types = append(types, t)
+	}
+	a := New(types...)
+	salt, err := c.Salt.salt()
+	if err != nil {
+		report("salt: %v", err)
+	}
+	if salt != nil {
+		a.SetSalt(salt)
+	}
+	if c.Window != "" {
+		window, err := ParseWindow(c.Window)
+		if err != nil {
+			report("window: %v", err)
+		}
+		a.SetWindow(window)
+	}
+	if c.Entropy != nil {
+		options, err := c.Entropy.options()
+		if err != nil {
+			report("entropy: %v", err)
+		}
+		a.SetEntropyOptions(options)
+	}
+	if c.SecretKeys != nil {
+		a.SetSecretKeys(c.SecretKeys...)
+	}
+	if c.IPPolicy != "" {
+		policy, err := ParseIPPolicy(c.IPPolicy)
+		if err != nil {
+			report("ip_policy: %v", err)
+		}
+		a.SetIPPolicy(policy)
+	}
+	if c.TokenLength < 0 {
+		report("token_length: should not be negative")
+	}
+	if c.TokenLength > 0 {
+		a.SetTokenLength(c.TokenLength)
+	}
+	a.SetLegacyTokens(c.LegacyTokens)
+	if c.Template != "" {
+		if !strings.Contains(c.Template, "{token}") {
+			report("template: %q should contain {token}", c.Template)
+		}
+		a.SetTemplate(c.Template)
+	}
+	if c.Strategy != nil {
+		strategy, err := c.Strategy.strategy()
+		if err != nil {
+			report("strategy: %v", err)
+		}
+		a.SetStrategy(strategy)
+	}
+	names := make([]string, 0, len(c.Strategies))
+	for name := range c.Strategies {
+		names = append(names, name)
+	}
+	sortSlice(names)
+	for _, name := range names {
+		sc := c.Strategies[name]
+		t, err := ParseDataType(name)
+		if err != nil {
+			report("strategies: %v", err)
+			continue
+		}
+		strategy, err := sc.strategy()
+		if err != nil {
+			report("strategies[%s]: %v", name, err)
+			continue
+		}
+		a.SetStrategy(strategy, t)
+	}
+	for i, p := range c.Patterns {
+		if p.Prefix == "" {
+			report("patterns[%d]: missing prefix", i)
+		}
+		if p.Regex == "" {
+			report("patterns[%d]: missing regex", i)
+			continue
+		}
+		regex, err := regexp.Compile(p.Regex)
+		if err != nil {
+			report("patterns[%d]: %v", i, err)
+			continue
+		}
+		if p.Example != "" && !regex.MatchString(p.Example) {
+			report("patterns[%d]: example %q does not match regex", i, p.Example)
+		}
+		a.AddConfidentialData(p.Prefix, regex, p.Example)
+		if p.Strategy == nil {
+			continue
+		}
+		strategy, err := p.Strategy.strategy()
+		if err != nil {
+			report("patterns[%d]: strategy: %v", i, err)
+			continue
+		}
+		a.confidentialDataList[len(a.confidentialDataList)-1].strategy = strategy
+	}
+	for i, domain := range c.Domains {
+		if domain == "" {
+			report("domains[%d]: empty domain", i)
+			continue
+		}
+		a.AddDomains(domain)
+	}
+	a.Allow(c.Allow...)
+	names = names[:0]
+	for name := range c.Allowlists {
+		names = append(names, name)
+	}
+	sortSlice(names)
+	for _, name := range names {
+		t, err := ParseDataType(name)
+		if err != nil {
+			report("allowlists: %v", err)
+			continue
+		}
+		for _, err := range c.Allowlists[name].apply(a, t) {
+			report("allowlists[%s]: %v", name, err)
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return a, nil
+}
+
+// options - return HighEntropy detector options.
+func (ec *EntropyConfig) options() (EntropyOptions, error) {
+	options := EntropyOptions{
+		MinLength:        ec.MinLength,
+		Base64Threshold:  ec.Base64Threshold,
+		HexThreshold:     ec.HexThreshold,
+		Keywords:         ec.Keywords,
+		KeywordMinLength: ec.KeywordMinLength,
+		KeywordThreshold: ec.KeywordThreshold,
+	}
+	if ec.MinLength < 0 || ec.KeywordMinLength < 0 || ec.Base64Threshold < 0 ||
+		ec.HexThreshold < 0 || ec.KeywordThreshold < 0 {
+		return options, errors.New("lengths and thresholds should not be negative")
+	}
+	if ec.Benign != nil {
+		// Empty list given explicitly disables DefaultEntropyBenign
+		options.Benign = make([]*regexp.Regexp, 0, len(ec.Benign))
+	}
+	for i, expr := range ec.Benign {
+		regex, err := regexp.Compile(expr)
+		if err != nil {
+			return options, fmt.Errorf("benign[%d]: %v", i, err)
+		}
+		options.Benign = append(options.Benign, regex)
+	}
+	return options, nil
+}
+
+// apply - add allowlist for data type t to the Anonymizer. Return list of errors.
+func (ac AllowConfig) apply(a *Anonymizer, t DataType) []error {
+	var errs []error
+	a.AllowValues(t, ac.Values...)
+	for i, network := range ac.Networks {
+		prefix, err := netip.ParsePrefix(network)
+		if err != nil {
+			if addr, addrErr := netip.ParseAddr(network); addrErr == nil {
+				prefix, err = addr.Prefix(addr.BitLen())
+			}
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("networks[%d]: %v", i, err))
+			continue
+		}
+		a.AllowNetworks(t, prefix)
+	}
+	a.AllowDomains(t, ac.Domains...)
+	for i, expr := range ac.Regexes {
+		regex, err := regexp.Compile(expr)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("regexes[%d]: %v", i, err))
+			continue
+		}
+		a.AllowRegex(t, regex)
+	}
+	return errs
+}
+
+// strategy - return configured strategy.
+func (sc *StrategyConfig) strategy() (Strategy, error) {
+	switch sc.Name {
+	case "", "hash":
+		return HashToken(), nil
+	case "redact":
+		if sc.Text == "" {
+			return nil, errors.New("redact: missing text")
+		}
+		return Redact(sc.Text), nil
+	case "mask":
+		if sc.Keep < 0 {
+			return nil, errors.New("mask: keep should not be negative")
+		}
+		mask := '*'
+		if sc.Mask != "" {
+			if utf8.RuneCountInString(sc.Mask) != 1 {
+				return nil, fmt.Errorf("mask: %q should be one character", sc.Mask)
+			}
+			mask, _ = utf8.DecodeRuneInString(sc.Mask)
+		}
+		return Mask(sc.Keep, mask), nil
+	case "truncate":
+		if sc.Length <= 0 {
+			return nil, errors.New("truncate: length should be positive")
+		}
+		return Truncate(sc.Length), nil
+	case "format":
+		return FormatPreserving(), nil
+	case "sequential":
+		return Sequential(), nil
+	}
+	return nil, fmt.Errorf("unknown strategy %q", sc.Name)
+}
+
+// salt - return configured salt. Nil means random salt.
+func (sc *SaltConfig) salt() ([]byte, error) {
+	count := 0
+	for _, v := range []string{sc.Value, sc.File, sc.Env} {
+		if v != "" {
+			count++
+		}
+	}
+	if count > 1 {
+		return nil, errors.New("only one of value, file and env can be set")
+	}
+	switch {
+	case sc.Value != "":
+		return []byte(sc.Value), nil
+	case sc.File != "":
+		data, err := os.ReadFile(sc.File)
+		if err != nil {
+			return nil, err
+		}
+		return []byte(strings.TrimRight(string(data), "\r\n")), nil
+	case sc.Env != "":
+		value, ok := os.LookupEnv(sc.Env)
+		if !ok {
+			return nil, fmt.Errorf("environment variable %s is not set", sc.Env)
+		}
+		return []byte(value), nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,140 @@
+/*
+Anon (c) 2023 by Mikhail Kondrashin (mkondrashin@gmail.com)
+github.com/mpkondrashin/anon
+
+config_test.go
+
+Configuration testing functions
+*/
+package anon
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestLoadConfig(t *testing.T) {
+	config := `
+types: [Email, CreditCard, IP4]
+patterns:
+  - prefix: ID
+    regex: 'ID-\d+'
+    example: ID-123
+  - prefix: PWD
+    regex: 'password=\S+'
+    strategy: {name: redact, text: "password=***"}
+domains: [corp]
+allow: [127.0.0.1]
+allowlists:
+  IP4: {networks: [192.168.0.0/16, 172.16.1.1]}
+  Email: {domains: [example.com], values: [a@b.org], regexes: ['^noreply@']}
+strategies:
+  CreditCard: {name: mask, keep: 4}
+salt:
+  value: secret
+template: "<{prefix} {token}>"
+token_length: 10
+`
+	a, err := LoadConfig(strings.NewReader(config))
+	if err != nil {
+		t.Fatal(err)
+	}
+	input := "ID-7 from 10.10.1.1 and 127.0.0.1 paid 4111111111111111 at db.corp with password=x" +
+		" 192.168.1.1 172.16.1.1 john@example.com a@b.org noreply@x.org"
+	expected := "<ID " + a.Token("ID-7") + "> from <IP " + a.Token("10.10.1.1") + "> and 127.0.0.1 paid ************1111 at <DNS " +
+		a.Token("db.corp") + "> with password=***" +
+		" 192.168.1.1 172.16.1.1 john@example.com a@b.org noreply@x.org"
+	actual := a.Anonymize(input)
+	if actual != expected {
+		t.Errorf("expected \"%s\", but got \"%s\"", expected, actual)
+	}
+	if len(a.Token("x")) != 10 {
+		t.Errorf("expected token length 10, but got %d", len(a.Token("x")))
+	}
+	if string(a.currentKey().Value) != "secret" {
+		t.Errorf("salt is not set")
+	}
+}
+
+func TestLoadConfigJSON(t *testing.T) {
+	a, err := LoadConfig(strings.NewReader(`{"types": ["IP4"], "salt": {"value": "s"}, "legacy_tokens": true}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := New(IP4).SetSalt([]byte("s")).SetLegacyTokens(true).Hide("1.1.1.1")
+	if actual := a.Hide("1.1.1.1"); actual != expected {
+		t.Errorf("expected \"%s\", but got \"%s\"", expected, actual)
+	}
+}
+
+func TestLoadConfigEntropy(t *testing.T) {
+	input := "commit 4b825dc642cb6eb9a060e54bf8d69288fbee4904"
+	for config, expected := range map[string]int{
+		"types: [HighEntropy]":                                  0,
+		"types: [HighEntropy]\nentropy: {min_length: 24}":       0,
+		"types: [HighEntropy]\nentropy: {benign: []}":           1,
+		`{"types": ["HighEntropy"], "entropy": {"benign": []}}`: 1,
+	} {
+		a, err := LoadConfig(strings.NewReader(config))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual := len(a.Scan(input)); actual != expected {
+			t.Errorf("%s: expected %d findings, but got %d", config, expected, actual)
+		}
+	}
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	config := `
+types: [Email, Phone]
+patterns:
+  - prefix: ID
+    regex: '('
+  - regex: 'X'
+    example: 'Y'
+allowlists:
+  Fax: {values: [x]}
+  IP4: {networks: [10.0.0.0/33], regexes: ['(']}
+strategies:
+  Fax: {name: hash}
+  IP4: {name: shred}
+salt:
+  value: a
+  env: B
+window: month
+ip_policy: some
+entropy: {min_length: -1, benign: ['(']}
+template: "{prefix}"
+`
+	_, err := LoadConfig(strings.NewReader(config))
+	if !errors.Is(err, ErrConfig) {
+		t.Fatalf("expected ErrConfig, but got %v", err)
+	}
+	for _, expected := range []string{"types[1]", "patterns[0]", "patterns[1]: missing prefix",
+		"example \"Y\"", "strategies: unknown DataType: Fax", "strategies[IP4]", "allowlists: unknown DataType: Fax", "allowlists[IP4]: networks[0]",
+		"allowlists[IP4]: regexes[0]", "salt:", "window:", "ip_policy:", "entropy:", "template:"} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("expected %q in error:\n%v", expected, err)
+		}
+	}
+	_, err = LoadConfig(strings.NewReader("typo: 1"))
+	if !errors.Is(err, ErrConfig) {
+		t.Errorf("expected ErrConfig, but got %v", err)
+	}
+}
+
+func ExampleLoadConfig() {
+	config := `
+types: [IP4]
+salt: {value: example}
+`
+	a, err := LoadConfig(strings.NewReader(config))
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(a.Anonymize("My address is 10.10.1.1"))
+	// Output: My address is IP:GR4SItgGHXqR91cxVo1JnND9pg4
+}
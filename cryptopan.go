@@ -0,0 +1,86 @@
+/*
+Anon (c) 2023 by Mikhail Kondrashin (mkondrashin@gmail.com)
+github.com/mpkondrashin/anon
+
+cryptopan.go
+
+Prefix-preserving IP addresses pseudonymization (Crypto-PAn).
+*/
+package anon
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"net/netip"
+)
+
+// cryptoPAn - prefix-preserving IP addresses anonymizer. If two addresses share
+// first n bits, their anonymized values will share first n bits as well.
+type cryptoPAn struct {
+	block cipher.Block
+	pad   [aes.BlockSize]byte
+}
+
+// newCryptoPAn - return Crypto-PAn anonymizer for 32 bytes key:
+// first half is AES key and second half is encrypted to get the pad.
+func newCryptoPAn(key []byte) *cryptoPAn {
+	block, err := aes.NewCipher(key[:16])
+	if err != nil {
+		panic(err)
+	}
+	c := &cryptoPAn{block: block}
+	block.Encrypt(c.pad[:], key[16:32])
+	return c
+}
+
+// newCryptoPAnFromSalt - return Crypto-PAn anonymizer with key derived from salt.
+func newCryptoPAnFromSalt(salt []byte) *cryptoPAn {
+	key := sha256.Sum256(salt)
+	return newCryptoPAn(key[:])
+}
+
+// anonymize - return anonymized address bytes (4 bytes for IPv4 or 16 bytes for IPv6).
+func (c *cryptoPAn) anonymize(addr []byte) []byte {
+	result := make([]byte, len(addr))
+	var in, out [aes.BlockSize]byte
+	for i := 0; i < len(addr)*8; i++ {
+		in = c.pad
+		full := i / 8
+		copy(in[:full], addr[:full])
+		if rest := i % 8; rest != 0 {
+			mask := byte(0xFF) << (8 - rest)
+			in[full] = addr[full]&mask | c.pad[full]&^mask
+		}
+		c.block.Encrypt(out[:], in[:])
+		result[i/8] |= (out[0] >> 7) << (7 - i%8)
+	}
+	for i := range result {
+		result[i] ^= addr[i]
+	}
+	return result
+}
+
+// anonymizeAddr - return anonymized address of the same family. Zone is kept as is.
+func (c *cryptoPAn) anonymizeAddr(addr netip.Addr) netip.Addr {
+	if addr.Is4() {
+		a4 := addr.As4()
+		var r4 [4]byte
+		copy(r4[:], c.anonymize(a4[:]))
+		return netip.AddrFrom4(r4)
+	}
+	a16 := addr.As16()
+	var r16 [16]byte
+	copy(r16[:], c.anonymize(a16[:]))
+	return netip.AddrFrom16(r16).WithZone(addr.Zone())
+}
+
+// anonymizeString - return anonymized address for its string representation.
+// If s is not an IP address, false is returned.
+func (c *cryptoPAn) anonymizeString(s string) (string, bool) {
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return "", false
+	}
+	return c.anonymizeAddr(addr).String(), true
+}
@@ -0,0 +1,70 @@
+/*
+Anon (c) 2023 by Mikhail Kondrashin (mkondrashin@gmail.com)
+github.com/mpkondrashin/anon
+
+cryptopan_test.go
+
+Crypto-PAn testing functions
+*/
+package anon
+
+import (
+	"net/netip"
+	"testing"
+)
+
+func TestCryptoPAnReference(t *testing.T) {
+	key := []byte{21, 34, 23, 141, 51, 164, 207, 128, 19, 10, 91, 22, 73, 144, 125, 16,
+		216, 152, 143, 131, 121, 121, 101, 39, 98, 87, 76, 45, 42, 132, 34, 2}
+	c := newCryptoPAn(key)
+	tCases := []struct {
+		input    string
+		expected string
+	}{
+		{"128.11.68.132", "135.242.180.132"},
+		{"129.118.74.4", "134.136.186.123"},
+		{"130.132.252.244", "133.68.164.234"},
+		{"141.223.7.43", "141.167.8.160"},
+		{"141.233.145.108", "141.129.237.235"},
+	}
+	for _, tCase := range tCases {
+		t.Run(tCase.input, func(t *testing.T) {
+			actual, _ := c.anonymizeString(tCase.input)
+			if actual != tCase.expected {
+				t.Errorf("%s: expected %s but got %s", tCase.input, tCase.expected, actual)
+			}
+		})
+	}
+}
+
+func TestCryptoPAnPrefix(t *testing.T) {
+	c := newCryptoPAnFromSalt([]byte("salt"))
+	tCases := []struct {
+		a, b string
+		bits int
+	}{
+		{"192.168.10.1", "192.168.10.200", 24},
+		{"10.1.2.3", "10.200.2.3", 8},
+		{"2001:db8:1:2::1", "2001:db8:1:2::ffff", 112},
+		{"2001:db8::1", "2001:db9::1", 31},
+	}
+	for _, tCase := range tCases {
+		t.Run(tCase.a+" "+tCase.b, func(t *testing.T) {
+			a := c.anonymizeAddr(netip.MustParseAddr(tCase.a))
+			b := c.anonymizeAddr(netip.MustParseAddr(tCase.b))
+			if a.Is4() != netip.MustParseAddr(tCase.a).Is4() {
+				t.Errorf("address family changed: %v", a)
+			}
+			pa, _ := a.Prefix(tCase.bits)
+			pb, _ := b.Prefix(tCase.bits)
+			if pa != pb {
+				t.Errorf("%v and %v do not share /%d", a, b, tCase.bits)
+			}
+			pa, _ = a.Prefix(tCase.bits + 1)
+			pb, _ = b.Prefix(tCase.bits + 1)
+			if pa == pb {
+				t.Errorf("%v and %v share /%d", a, b, tCase.bits+1)
+			}
+		})
+	}
+}
@@ -0,0 +1,230 @@
+/*
+Anon (c) 2023 by Mikhail Kondrashin (mkondrashin@gmail.com)
+github.com/mpkondrashin/anon
+
+entropy.go
+
+High entropy strings detector for secrets of unknown format
+*/
+package anon
+
+import (
+	"encoding/base64"
+	"math"
+	"regexp"
+	"strings"
+)
+
+// Default values of EntropyOptions.
+const (
+	DefaultEntropyMinLength        = 20
+	DefaultEntropyBase64Threshold  = 4.0
+	DefaultEntropyHexThreshold     = 3.0
+	DefaultEntropyKeywordMinLength = 8
+	DefaultEntropyKeywordThreshold = 3.0
+)
+
+// DefaultEntropyKeywords - key names making shorter and less random values suspicious.
+var DefaultEntropyKeywords = []string{"secret", "token", "password", "passwd", "pwd",
+	"key", "auth", "credential", "session", "cookie"}
+
+// DefaultEntropyBenign - high entropy strings that are not secrets: full SHA-1 and SHA-256
+// commit hashes in lower case hex. Abbreviated hashes are shorter than DefaultEntropyMinLength.
+var DefaultEntropyBenign = []*regexp.Regexp{
+	regexp.MustCompile(`^[0-9a-f]{40}$`),
+	regexp.MustCompile(`^[0-9a-f]{64}$`),
+}
+
+// EntropyOptions - settings of the HighEntropy detector. Zero values mean defaults.
+type EntropyOptions struct {
+	// MinLength - minimal length of the value.
+	MinLength int
+	// Base64Threshold - minimal Shannon entropy (bits per character) of base64 values.
+	Base64Threshold float64
+	// HexThreshold - minimal Shannon entropy (bits per character) of hex values.
+	HexThreshold float64
+	// Keywords - if value follows one of them (like "secret=", "token: " or "--api-key "),
+	// KeywordMinLength and KeywordThreshold are used instead. Case is ignored.
+	Keywords []string
+	// KeywordMinLength - minimal length of the value following keyword.
+	KeywordMinLength int
+	// KeywordThreshold - minimal Shannon entropy of the value following keyword.
+	KeywordThreshold float64
+	// Benign - values matching any of these regexes are not reported unless they follow
+	// keyword. Nil means DefaultEntropyBenign, use empty slice to report all values.
+	Benign []*regexp.Regexp
+}
+
+// entropyDetector - HighEntropy detector with options applied.
+type entropyDetector struct {
+	EntropyOptions
+	keyword *regexp.Regexp
+}
+
+// defaultEntropyDetector - detector used if options are not set.
+var defaultEntropyDetector = newEntropyDetector(EntropyOptions{})
+
+// newEntropyDetector - return detector with defaults filled.
+func newEntropyDetector(options EntropyOptions) *entropyDetector {
+	if options.MinLength == 0 {
+		options.MinLength = DefaultEntropyMinLength
+	}
+	if options.Base64Threshold == 0 {
+		options.Base64Threshold = DefaultEntropyBase64Threshold
+	}
+	if options.HexThreshold == 0 {
+		options.HexThreshold = DefaultEntropyHexThreshold
+	}
+	if options.Keywords == nil {
+		options.Keywords = DefaultEntropyKeywords
+	}
+	if options.KeywordMinLength == 0 {
+		options.KeywordMinLength = DefaultEntropyKeywordMinLength
+	}
+	if options.KeywordThreshold == 0 {
+		options.KeywordThreshold = DefaultEntropyKeywordThreshold
+	}
+	if options.Benign == nil {
+		options.Benign = DefaultEntropyBenign
+	}
+	d := &entropyDetector{EntropyOptions: options}
+	if len(options.Keywords) > 0 {
+		quoted := make([]string, len(options.Keywords))
+		for i, k := range options.Keywords {
+			quoted[i] = regexp.QuoteMeta(k)
+		}
+		keyword := `(?:` + strings.Join(quoted, "|") + `)[A-Za-z0-9_.-]*`
+		// Value follows "key=", "key: ", "key": " or command line flag like "--api-key "
+		d.keyword = regexp.MustCompile(`(?i)(?:` + keyword + `["']?\s*[:=]\s*["']?|(?:^|\s)--?[A-Za-z0-9_.-]*` + keyword + `\s+)$`)
+	}
+	return d
+}
+
+// SetEntropyOptions - set options of the HighEntropy detector.
+func (a *Anonymizer) SetEntropyOptions(options EntropyOptions) *Anonymizer {
+	a.entropy = newEntropyDetector(options)
+	return a
+}
+
+// rxEntropyCandidate - strings of base64 (including URL safe) and hex characters.
+var rxEntropyCandidate = mustCompileLongest(`[A-Za-z0-9+/_-]+={0,2}`)
+
+// maxSlashShare - base64 strings have '/' once in 64 characters on average, while paths
+// have it every few characters. Candidates having more slashes are split on them.
+const maxSlashShare = 16
+
+// keywordLookBehind - how many bytes before the value are searched for keyword.
+const keywordLookBehind = 64
+
+// findHighEntropy - return locations of the high entropy values in input.
+func findHighEntropy(a *Anonymizer, input string) [][]int {
+	d := a.entropy
+	if d == nil {
+		d = defaultEntropyDetector
+	}
+	var result [][]int
+	tokens := a.tokens(input)
+	for _, candidate := range rxEntropyCandidate.FindAllStringIndex(input, -1) {
+		for _, loc := range splitPath(input, candidate) {
+			value := input[loc[0]:loc[1]]
+			keyword := d.afterKeyword(input[:loc[0]])
+			if !d.suspicious(value, keyword) {
+				continue
+			}
+			if (!keyword && d.benign(value)) || isToken(tokens, loc[0], loc[1]) {
+				continue
+			}
+			result = append(result, loc)
+		}
+	}
+	return result
+}
+
+// splitPath - return candidate as is if it looks like base64 or its parts between
+// slashes otherwise, so paths like "/api/v1/users/12345" are not taken for secrets.
+// Candidate looks like base64 if it is valid padded base64 with no leading, trailing
+// or double slashes and slashes are rare.
+func splitPath(input string, loc []int) [][]int {
+	value := input[loc[0]:loc[1]]
+	slashes := strings.Count(value, "/")
+	if slashes == 0 {
+		return [][]int{loc}
+	}
+	if len(value)%4 == 0 && slashes*maxSlashShare <= len(value) &&
+		value[0] != '/' && !strings.HasSuffix(strings.TrimRight(value, "="), "/") &&
+		!strings.Contains(value, "//") {
+		if _, err := base64.StdEncoding.DecodeString(value); err == nil {
+			return [][]int{loc}
+		}
+	}
+	var result [][]int
+	start := loc[0]
+	for _, part := range strings.Split(value, "/") {
+		if part != "" {
+			result = append(result, []int{start, start + len(part)})
+		}
+		start += len(part) + 1
+	}
+	return result
+}
+
+// suspicious - return true if value is long and random enough.
+func (d *entropyDetector) suspicious(value string, keyword bool) bool {
+	if keyword {
+		return len(value) >= d.KeywordMinLength && shannon(value) >= d.KeywordThreshold
+	}
+	if len(value) < d.MinLength {
+		return false
+	}
+	if strings.Trim(value, "0123456789abcdefABCDEF") == "" {
+		return shannon(value) >= d.HexThreshold
+	}
+	return lettersAndDigits(value) && shannon(value) >= d.Base64Threshold
+}
+
+// afterKeyword - return true if text before the value on the same line ends with keyword.
+func (d *entropyDetector) afterKeyword(before string) bool {
+	if d.keyword == nil {
+		return false
+	}
+	if i := strings.LastIndexByte(before, '\n'); i >= 0 {
+		before = before[i+1:]
+	}
+	if len(before) > keywordLookBehind {
+		before = before[len(before)-keywordLookBehind:]
+	}
+	return d.keyword.MatchString(before)
+}
+
+// benign - return true if value is known not to be a secret.
+func (d *entropyDetector) benign(value string) bool {
+	for _, rx := range d.Benign {
+		if rx.MatchString(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// shannon - return Shannon entropy of the string in bits per character.
+func shannon(s string) float64 {
+	counts := make(map[rune]int)
+	n := 0
+	for _, c := range s {
+		counts[c]++
+		n++
+	}
+	var result float64
+	for _, count := range counts {
+		p := float64(count) / float64(n)
+		result -= p * math.Log2(p)
+	}
+	return result
+}
+
+// lettersAndDigits - return true if s has both letters and digits, so identifiers
+// like "NewRandomStringDetector" are not taken for secrets.
+func lettersAndDigits(s string) bool {
+	return strings.ContainsAny(s, "0123456789") &&
+		strings.IndexFunc(s, func(c rune) bool { return c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' }) >= 0
+}
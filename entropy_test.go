@@ -0,0 +1,124 @@
+/*
+Anon (c) 2023 by Mikhail Kondrashin (mkondrashin@gmail.com)
+github.com/mpkondrashin/anon
+
+entropy_test.go
+
+High entropy detector testing functions
+*/
+package anon
+
+import (
+	"math"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestShannon(t *testing.T) {
+	tCases := []struct {
+		input    string
+		expected float64
+	}{
+		{"aaaa", 0},
+		{"abab", 1},
+		{"abcd", 2},
+		{"0123456789abcdef", 4},
+	}
+	for _, tCase := range tCases {
+		if actual := shannon(tCase.input); math.Abs(actual-tCase.expected) > 1e-9 {
+			t.Errorf("%s: expected %v, but got %v", tCase.input, tCase.expected, actual)
+		}
+	}
+}
+
+func TestHighEntropy(t *testing.T) {
+	tCases := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"base64", "value Zx8Kq2Lm9Vb4Nw7Tr1Yp3Hs6Jd0Fg5 end", "Zx8Kq2Lm9Vb4Nw7Tr1Yp3Hs6Jd0Fg5"},
+		{"hex", "id 8f3a9c2e7b1d4f6a0e5c9b2d7f1a3e8c4b6d0f2a9e7c", "8f3a9c2e7b1d4f6a0e5c9b2d7f1a3e8c4b6d0f2a9e7c"},
+		{"words", "ThisIsAVeryLongIdentifierName", ""},
+		{"path", "/usr/local/share/applications/default", ""},
+		{"short", "Zx8Kq2Lm9Vb4", ""},
+		{"keyword", "secret=Zx8Kq2Lm9Vb4", "Zx8Kq2Lm9Vb4"},
+		{"keyword quoted", `"api_token": "Zx8Kq2Lm9Vb4"`, "Zx8Kq2Lm9Vb4"},
+		{"keyword flag", "--token Zx8Kq2Lm9Vb4", "Zx8Kq2Lm9Vb4"},
+		{"keyword flag suffix", "app --api-key Zx8Kq2Lm9Vb4", "Zx8Kq2Lm9Vb4"},
+		{"word after keyword", "user session established successfully", ""},
+		{"word after keyword with space", "secret tokenization", ""},
+		{"keyword low entropy", "password=aaaaaaaaaa", ""},
+		{"keyword other line", "secret\nZx8Kq2Lm9Vb4", ""},
+		{"commit hash", "commit 4b825dc642cb6eb9a060e54bf8d69288fbee4904", ""},
+		{"sha256 hash", "sha256 9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08", ""},
+		{"abbreviated commit hash", "commit 4b825dc", ""},
+		{"hex API key", "key 4B825DC642CB6EB9A060E54BF8D69288FBEE4904", "4B825DC642CB6EB9A060E54BF8D69288FBEE4904"},
+		{"hex 32", "id 8f3a9c2e7b1d4f6a0e5c9b2d7f1a3e8c", "8f3a9c2e7b1d4f6a0e5c9b2d7f1a3e8c"},
+		{"hex after keyword", "token=4b825dc642cb6eb9a060", "4b825dc642cb6eb9a060"},
+		{"REST path", "GET /api/v1/users/12345/orders/67890/items", ""},
+		{"file path", "/var/lib/docker/overlay2/3f4e5d6c7b8a/merged/usr/bin", ""},
+		{"URL", "https://example.com/v2/projects/987654321/builds/a1b2c3d4e5/logs?page=2", ""},
+		{"secret in path", "/callback/Zx8Kq2Lm9Vb4Nw7Tr1Yp3Hs6Jd0Fg5/done", "Zx8Kq2Lm9Vb4Nw7Tr1Yp3Hs6Jd0Fg5"},
+		{"base64 with slashes", "aws wJalrXUtnFEMI/K7MDENG/bPxRfiCYEXAMPLEKEY end", "wJalrXUtnFEMI/K7MDENG/bPxRfiCYEXAMPLEKEY"},
+	}
+	a := New(HighEntropy)
+	for _, tCase := range tCases {
+		t.Run(tCase.name, func(t *testing.T) {
+			actual := ""
+			if findings := a.ScanValues(tCase.input); len(findings) > 0 {
+				actual = findings[0].Value
+			}
+			if actual != tCase.expected {
+				t.Errorf("%s: expected \"%s\", but got \"%s\"", tCase.input, tCase.expected, actual)
+			}
+		})
+	}
+}
+
+func TestHighEntropyOwnTokens(t *testing.T) {
+	keys, err := RotatingKeys("k1", []byte("master"), 24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, a := range []*Anonymizer{
+		New(HighEntropy, Email),
+		New(HighEntropy, Email).SetTemplate("<{prefix} {token}>").SetTokenLength(0),
+		New(HighEntropy, Email).SetKeyProvider(keys),
+	} {
+		once := a.Anonymize("mail john@example.com with Zx8Kq2Lm9Vb4Nw7Tr1Yp3Hs6Jd0Fg5")
+		if twice := a.Anonymize(once); twice != once {
+			t.Errorf("tokens anonymized again: \"%s\" -> \"%s\"", once, twice)
+		}
+	}
+	for _, tCase := range []struct {
+		a     *Anonymizer
+		input string
+	}{
+		{New(HighEntropy).SetTemplate("{token}"), "key Zx8Kq2Lm9Vb4Nw7Tr1Yp3Hs6Jd0Fg5"},
+		{New(HighEntropy, Email), "Email:Zx8Kq2Lm9Vb4Nw7Tr1Yp3Hs6Jd0Fg5"},
+	} {
+		if actual := tCase.a.Anonymize(tCase.input); strings.Contains(actual, "Zx8Kq2Lm9Vb4Nw7Tr1Yp3Hs6Jd0Fg5") {
+			t.Errorf("%s: secret is not anonymized: \"%s\"", tCase.input, actual)
+		}
+	}
+}
+
+func TestEntropyOptions(t *testing.T) {
+	input := "id 4b825dc642cb6eb9a060e54bf8d69288fbee4904 key Zx8Kq2Lm9Vb4"
+	a := New(HighEntropy).SetEntropyOptions(EntropyOptions{
+		Keywords: []string{},
+		Benign:   []*regexp.Regexp{},
+	})
+	findings := a.ScanValues(input)
+	if len(findings) != 1 || findings[0].Value != "4b825dc642cb6eb9a060e54bf8d69288fbee4904" {
+		t.Errorf("unexpected findings %v", findings)
+	}
+	a.SetEntropyOptions(EntropyOptions{MinLength: 8, Base64Threshold: 3})
+	findings = a.ScanValues(input)
+	if len(findings) != 1 || findings[0].Value != "Zx8Kq2Lm9Vb4" {
+		t.Errorf("unexpected findings %v", findings)
+	}
+}
@@ -12,43 +12,77 @@ import (
 type DataType int
 
 const (
-	Email      DataType = iota
-	CreditCard DataType = iota
-	UUID3      DataType = iota
-	UUID4      DataType = iota
-	UUID5      DataType = iota
-	UUID       DataType = iota
-	Latitude   DataType = iota
-	Longitude  DataType = iota
-	IP4        DataType = iota
-	IP6        DataType = iota
-	DNSName    DataType = iota
-	URL        DataType = iota
-	SSN        DataType = iota
-	IMEI       DataType = iota
-	IMSI       DataType = iota
-	E164       DataType = iota
+	Email           DataType = iota
+	CreditCard      DataType = iota
+	UUID3           DataType = iota
+	UUID4           DataType = iota
+	UUID5           DataType = iota
+	UUID            DataType = iota
+	Latitude        DataType = iota
+	Longitude       DataType = iota
+	IP4             DataType = iota
+	IP6             DataType = iota
+	DNSName         DataType = iota
+	URL             DataType = iota
+	SSN             DataType = iota
+	IMEI            DataType = iota
+	IMSI            DataType = iota
+	E164            DataType = iota
+	AWSAccessKey    DataType = iota
+	AWSSecretKey    DataType = iota
+	GitHubToken     DataType = iota
+	GitLabToken     DataType = iota
+	SlackToken      DataType = iota
+	GoogleAPIKey    DataType = iota
+	StripeKey       DataType = iota
+	JWT             DataType = iota
+	BearerToken     DataType = iota
+	PrivateKey      DataType = iota
+	HighEntropy     DataType = iota
+	KeyValueSecret  DataType = iota
+	IBAN            DataType = iota
+	BIC             DataType = iota
+	ABARouting      DataType = iota
+	BitcoinAddress  DataType = iota
+	EthereumAddress DataType = iota
 )
 
 // String - return string representation for DataType value
 func (v DataType) String() string {
 	s, ok := map[DataType]string{
-		Email:      "Email",
-		CreditCard: "CreditCard",
-		UUID3:      "UUID3",
-		UUID4:      "UUID4",
-		UUID5:      "UUID5",
-		UUID:       "UUID",
-		Latitude:   "Latitude",
-		Longitude:  "Longitude",
-		IP4:        "IP4",
-		IP6:        "IP6",
-		DNSName:    "DNSName",
-		URL:        "URL",
-		SSN:        "SSN",
-		IMEI:       "IMEI",
-		IMSI:       "IMSI",
-		E164:       "E164",
+		Email:           "Email",
+		CreditCard:      "CreditCard",
+		UUID3:           "UUID3",
+		UUID4:           "UUID4",
+		UUID5:           "UUID5",
+		UUID:            "UUID",
+		Latitude:        "Latitude",
+		Longitude:       "Longitude",
+		IP4:             "IP4",
+		IP6:             "IP6",
+		DNSName:         "DNSName",
+		URL:             "URL",
+		SSN:             "SSN",
+		IMEI:            "IMEI",
+		IMSI:            "IMSI",
+		E164:            "E164",
+		AWSAccessKey:    "AWSAccessKey",
+		AWSSecretKey:    "AWSSecretKey",
+		GitHubToken:     "GitHubToken",
+		GitLabToken:     "GitLabToken",
+		SlackToken:      "SlackToken",
+		GoogleAPIKey:    "GoogleAPIKey",
+		StripeKey:       "StripeKey",
+		JWT:             "JWT",
+		BearerToken:     "BearerToken",
+		PrivateKey:      "PrivateKey",
+		HighEntropy:     "HighEntropy",
+		KeyValueSecret:  "KeyValueSecret",
+		IBAN:            "IBAN",
+		BIC:             "BIC",
+		ABARouting:      "ABARouting",
+		BitcoinAddress:  "BitcoinAddress",
+		EthereumAddress: "EthereumAddress",
 	}[v]
 	if ok {
 		return s
@@ -61,22 +95,39 @@ func (v DataType) String() string {
 var ErrUnknownDataType = errors.New("unknown DataType")
 
 var mapDataTypeFromString = map[string]DataType{
-	"Email":      Email,
-	"CreditCard": CreditCard,
-	"UUID3":      UUID3,
-	"UUID4":      UUID4,
-	"UUID5":      UUID5,
-	"UUID":       UUID,
-	"Latitude":   Latitude,
-	"Longitude":  Longitude,
-	"IP4":        IP4,
-	"IP6":        IP6,
-	"DNSName":    DNSName,
-	"URL":        URL,
-	"SSN":        SSN,
-	"IMEI":       IMEI,
-	"IMSI":       IMSI,
-	"E164":       E164,
+	"Email":           Email,
+	"CreditCard":      CreditCard,
+	"UUID3":           UUID3,
+	"UUID4":           UUID4,
+	"UUID5":           UUID5,
+	"UUID":            UUID,
+	"Latitude":        Latitude,
+	"Longitude":       Longitude,
+	"IP4":             IP4,
+	"IP6":             IP6,
+	"DNSName":         DNSName,
+	"URL":             URL,
+	"SSN":             SSN,
+	"IMEI":            IMEI,
+	"IMSI":            IMSI,
+	"E164":            E164,
+	"AWSAccessKey":    AWSAccessKey,
+	"AWSSecretKey":    AWSSecretKey,
+	"GitHubToken":     GitHubToken,
+	"GitLabToken":     GitLabToken,
+	"SlackToken":      SlackToken,
+	"GoogleAPIKey":    GoogleAPIKey,
+	"StripeKey":       StripeKey,
+	"JWT":             JWT,
+	"BearerToken":     BearerToken,
+	"PrivateKey":      PrivateKey,
+	"HighEntropy":     HighEntropy,
+	"KeyValueSecret":  KeyValueSecret,
+	"IBAN":            IBAN,
+	"BIC":             BIC,
+	"ABARouting":      ABARouting,
+	"BitcoinAddress":  BitcoinAddress,
+	"EthereumAddress": EthereumAddress,
 }
 
 // UnmarshalJSON implements the Unmarshaler interface of the json package for DataType.
@@ -0,0 +1,342 @@
+/*
+Anon (c) 2023 by Mikhail Kondrashin (mkondrashin@gmail.com)
+github.com/mpkondrashin/anon
+
+finance.go
+
+Detectors and validators for financial identifiers: IBAN, SWIFT/BIC,
+ABA routing numbers and crypto currency wallet addresses
+*/
+package anon
+
+import (
+	"crypto/sha256"
+	"regexp"
+	"strings"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// ibanLengths - IBAN length for each country according to the IBAN registry.
+var ibanLengths = map[string]int{
+	"AD": 24, "AE": 23, "AL": 28, "AT": 20, "AZ": 28, "BA": 20, "BE": 16, "BG": 22,
+	"BH": 22, "BI": 27, "BR": 29, "BY": 28, "CH": 21, "CR": 22, "CY": 28, "CZ": 24,
+	"DE": 22, "DJ": 27, "DK": 18, "DO": 28, "EE": 20, "EG": 29, "ES": 24, "FI": 18,
+	"FK": 18, "FO": 18, "FR": 27, "GB": 22, "GE": 22, "GI": 23, "GL": 18, "GR": 27,
+	"GT": 28, "HR": 21, "HU": 28, "IE": 22, "IL": 23, "IQ": 23, "IS": 26, "IT": 27,
+	"JO": 30, "KW": 30, "KZ": 20, "LB": 28, "LC": 32, "LI": 21, "LT": 20, "LU": 20,
+	"LV": 21, "LY": 25, "MC": 27, "MD": 24, "ME": 22, "MK": 19, "MN": 20, "MR": 27,
+	"MT": 31, "MU": 30, "NI": 28, "NL": 18, "NO": 15, "OM": 23, "PK": 24, "PL": 28,
+	"PS": 29, "PT": 25, "QA": 29, "RO": 24, "RS": 22, "RU": 33, "SA": 24, "SC": 31,
+	"SD": 18, "SE": 24, "SI": 19, "SK": 24, "SM": 27, "SO": 23, "ST": 25, "SV": 28,
+	"TL": 23, "TN": 24, "TR": 26, "UA": 29, "VA": 22, "VG": 24, "XK": 20,
+}
+
+// findIBAN - return locations of valid IBANs in input. IBAN can be written
+// electronically (without spaces) or in print format (groups of four characters
+// separated by single spaces). Its end is found using length for the country.
+func findIBAN(_ *Anonymizer, input string) [][]int {
+	var result [][]int
+	last := 0
+	for _, loc := range rxIBANStart.FindAllStringIndex(input, -1) {
+		if loc[0] < last {
+			continue
+		}
+		length, ok := ibanLengths[input[loc[0]:loc[0]+2]]
+		if !ok {
+			continue
+		}
+		end, iban := ibanEnd(input, loc[0], length)
+		if end < 0 || !validIBAN(iban) {
+			continue
+		}
+		result = append(result, []int{loc[0], end})
+		last = end
+	}
+	return result
+}
+
+// ibanEnd - return end of IBAN of given length starting at start and IBAN itself
+// without spaces. -1 is returned if there is no IBAN of such length.
+func ibanEnd(input string, start, length int) (int, string) {
+	var sb strings.Builder
+	i := start
+	for i < len(input) && sb.Len() < length {
+		c := input[i]
+		switch {
+		case c >= 'A' && c <= 'Z' || c >= '0' && c <= '9':
+			sb.WriteByte(c)
+		case c == ' ' && sb.Len()%4 == 0 && i+1 < len(input) && input[i+1] != ' ':
+		default:
+			return -1, ""
+		}
+		i++
+	}
+	if sb.Len() < length || (i < len(input) && isAlphanumeric(input[i])) {
+		return -1, ""
+	}
+	return i, sb.String()
+}
+
+// isAlphanumeric - return true for ASCII letters and digits.
+func isAlphanumeric(c byte) bool {
+	return c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c >= '0' && c <= '9'
+}
+
+// validIBAN - check length for the country and mod-97 checksum of IBAN without spaces.
+func validIBAN(iban string) bool {
+	if len(iban) < 5 || ibanLengths[iban[:2]] != len(iban) {
+		return false
+	}
+	remainder := 0
+	for _, c := range iban[4:] + iban[:4] {
+		switch {
+		case c >= '0' && c <= '9':
+			remainder = (remainder*10 + int(c-'0')) % 97
+		case c >= 'A' && c <= 'Z':
+			remainder = (remainder*100 + int(c-'A') + 10) % 97
+		default:
+			return false
+		}
+	}
+	return remainder == 1
+}
+
+// isoCountries - ISO 3166-1 alpha-2 country codes (and XK used for Kosovo).
+var isoCountries = countrySet("AD AE AF AG AI AL AM AO AQ AR AS AT AU AW AX AZ " +
+	"BA BB BD BE BF BG BH BI BJ BL BM BN BO BQ BR BS BT BV BW BY BZ " +
+	"CA CC CD CF CG CH CI CK CL CM CN CO CR CU CV CW CX CY CZ " +
+	"DE DJ DK DM DO DZ EC EE EG EH ER ES ET FI FJ FK FM FO FR " +
+	"GA GB GD GE GF GG GH GI GL GM GN GP GQ GR GS GT GU GW GY " +
+	"HK HM HN HR HT HU ID IE IL IM IN IO IQ IR IS IT JE JM JO JP " +
+	"KE KG KH KI KM KN KP KR KW KY KZ LA LB LC LI LK LR LS LT LU LV LY " +
+	"MA MC MD ME MF MG MH MK ML MM MN MO MP MQ MR MS MT MU MV MW MX MY MZ " +
+	"NA NC NE NF NG NI NL NO NP NR NU NZ OM PA PE PF PG PH PK PL PM PN PR PS PT PW PY " +
+	"QA RE RO RS RU RW SA SB SC SD SE SG SH SI SJ SK SL SM SN SO SR SS ST SV SX SY SZ " +
+	"TC TD TF TG TH TJ TK TL TM TN TO TR TT TV TW TZ UA UG UM US UY UZ " +
+	"VA VC VE VG VI VN VU WF WS XK YE YT ZA ZM ZW")
+
+// countrySet - return set of space separated country codes.
+func countrySet(codes string) map[string]bool {
+	result := make(map[string]bool)
+	for _, code := range strings.Fields(codes) {
+		result[code] = true
+	}
+	return result
+}
+
+// validBIC - check country code and location code of SWIFT/BIC code.
+func validBIC(s string) bool {
+	if !isoCountries[s[4:6]] {
+		return false
+	}
+	// Letter O is not allowed as the second character of location code
+	return s[7] != 'O'
+}
+
+// financeLookBehind - number of characters before the value searched for keyword.
+const financeLookBehind = 32
+
+var (
+	rxBICKeyword = regexp.MustCompile(`(?i)\b(?:BIC|SWIFT)\b`)
+	rxABAKeyword = regexp.MustCompile(`(?i)\b(?:ABA|RTN|routing|transit)\b`)
+)
+
+// afterFinanceKeyword - return true if keyword is found on the same line shortly
+// before start.
+func afterFinanceKeyword(keyword *regexp.Regexp, input string, start int) bool {
+	before := input[:start]
+	if i := strings.LastIndexByte(before, '\n'); i >= 0 {
+		before = before[i+1:]
+	}
+	if len(before) > financeLookBehind {
+		before = before[len(before)-financeLookBehind:]
+	}
+	return keyword.MatchString(before)
+}
+
+// findBIC - return locations of SWIFT/BIC codes. As many upper case words (like
+// DATABASE or CALLBACK) look like BIC, code is reported only if its location code
+// has a digit or it follows BIC or SWIFT keyword.
+func findBIC(_ *Anonymizer, input string) [][]int {
+	var result [][]int
+	for _, loc := range rxBIC.FindAllStringIndex(input, -1) {
+		location := input[loc[0]+6 : loc[0]+8]
+		if strings.Trim(location, "0123456789") == location && !afterFinanceKeyword(rxBICKeyword, input, loc[0]) {
+			continue
+		}
+		result = append(result, loc)
+	}
+	return result
+}
+
+// findABARouting - return locations of ABA routing numbers. As many nine digit
+// numbers (like 123456780) pass the checksum, number is reported only if it follows
+// ABA, RTN, routing or transit keyword.
+func findABARouting(_ *Anonymizer, input string) [][]int {
+	var result [][]int
+	for _, loc := range rxABARouting.FindAllStringIndex(input, -1) {
+		if afterFinanceKeyword(rxABAKeyword, input, loc[0]) {
+			result = append(result, loc)
+		}
+	}
+	return result
+}
+
+// validABARouting - check prefix and checksum of US ABA routing number.
+func validABARouting(s string) bool {
+	if len(s) != 9 {
+		return false
+	}
+	d := make([]int, 9)
+	for i := range s {
+		d[i] = int(s[i] - '0')
+	}
+	prefix := d[0]*10 + d[1]
+	if !(prefix >= 1 && prefix <= 12 || prefix >= 21 && prefix <= 32 || prefix >= 61 && prefix <= 72 || prefix == 80) {
+		return false
+	}
+	sum := 3*(d[0]+d[3]+d[6]) + 7*(d[1]+d[4]+d[7]) + d[2] + d[5] + d[8]
+	return sum%10 == 0
+}
+
+// validBitcoinAddress - check checksum of Base58Check or Bech32 (Bech32m) Bitcoin address.
+func validBitcoinAddress(s string) bool {
+	lower := strings.ToLower(s)
+	if strings.HasPrefix(lower, "bc1") || strings.HasPrefix(lower, "tb1") {
+		return validSegwitAddress(lower)
+	}
+	return validBase58Address(s)
+}
+
+// base58Alphabet - Bitcoin Base58 alphabet.
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// base58Decode - decode Base58 string. Nil is returned for invalid input.
+func base58Decode(s string) []byte {
+	var result []byte
+	for i := 0; i < len(s); i++ {
+		carry := strings.IndexByte(base58Alphabet, s[i])
+		if carry < 0 {
+			return nil
+		}
+		for j := len(result) - 1; j >= 0; j-- {
+			carry += 58 * int(result[j])
+			result[j] = byte(carry)
+			carry >>= 8
+		}
+		for ; carry > 0; carry >>= 8 {
+			result = append([]byte{byte(carry)}, result...)
+		}
+	}
+	zeros := len(s) - len(strings.TrimLeft(s, "1"))
+	return append(make([]byte, zeros), result...)
+}
+
+// validBase58Address - check version and double SHA-256 checksum of P2PKH or P2SH address.
+func validBase58Address(s string) bool {
+	data := base58Decode(s)
+	if len(data) != 25 {
+		return false
+	}
+	switch data[0] {
+	case 0x00, 0x05, 0x6f, 0xc4:
+	default:
+		return false
+	}
+	first := sha256.Sum256(data[:21])
+	second := sha256.Sum256(first[:])
+	return string(second[:4]) == string(data[21:])
+}
+
+// bech32Alphabet - Bech32 alphabet.
+const bech32Alphabet = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+// Bech32 checksum constants (BIP 173 and BIP 350).
+const (
+	bech32Const  = 1
+	bech32mConst = 0x2bc830a3
+)
+
+// bech32Polymod - return Bech32 checksum polynomial of values.
+func bech32Polymod(values []byte) uint32 {
+	generator := [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+	chk := uint32(1)
+	for _, v := range values {
+		top := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+		for i := 0; i < 5; i++ {
+			if (top>>i)&1 == 1 {
+				chk ^= generator[i]
+			}
+		}
+	}
+	return chk
+}
+
+// validSegwitAddress - check checksum, witness version and program length of lower case
+// Bech32 (version 0) or Bech32m (versions 1-16) address.
+func validSegwitAddress(s string) bool {
+	if len(s) > 90 {
+		return false
+	}
+	hrp, encoded := s[:2], s[3:]
+	if len(encoded) < 7 {
+		return false
+	}
+	values := make([]byte, 0, 2*len(hrp)+1+len(encoded))
+	for i := 0; i < len(hrp); i++ {
+		values = append(values, hrp[i]>>5)
+	}
+	values = append(values, 0)
+	for i := 0; i < len(hrp); i++ {
+		values = append(values, hrp[i]&31)
+	}
+	data := make([]byte, len(encoded))
+	for i := 0; i < len(encoded); i++ {
+		v := strings.IndexByte(bech32Alphabet, encoded[i])
+		if v < 0 {
+			return false
+		}
+		data[i] = byte(v)
+	}
+	checksum := bech32Polymod(append(values, data...))
+	version := data[0]
+	switch {
+	case version == 0 && checksum != bech32Const:
+		return false
+	case version > 16 || version > 0 && checksum != bech32mConst:
+		return false
+	}
+	program := (len(data) - 7) * 5 / 8
+	if version == 0 {
+		return program == 20 || program == 32
+	}
+	return program >= 2 && program <= 40
+}
+
+// validEthereumAddress - check EIP-55 mixed case checksum of Ethereum address.
+// Addresses in single case have no checksum and are valid.
+func validEthereumAddress(s string) bool {
+	address := s[2:]
+	if strings.ToLower(address) == address || strings.ToUpper(address) == address {
+		return true
+	}
+	hash := sha3.NewLegacyKeccak256()
+	hash.Write([]byte(strings.ToLower(address)))
+	sum := hash.Sum(nil)
+	for i := 0; i < len(address); i++ {
+		c := address[i]
+		if c >= '0' && c <= '9' {
+			continue
+		}
+		nibble := sum[i/2] >> 4
+		if i%2 == 1 {
+			nibble = sum[i/2] & 0xf
+		}
+		if (nibble >= 8) != (c >= 'A' && c <= 'F') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,102 @@
+/*
+Anon (c) 2023 by Mikhail Kondrashin (mkondrashin@gmail.com)
+github.com/mpkondrashin/anon
+
+finance_test.go
+
+Financial identifiers testing functions
+*/
+package anon
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestFinance(t *testing.T) {
+	tCases := []struct {
+		dataType DataType
+		input    string
+		expected string
+	}{
+		{IBAN, "IBAN: GB82WEST12345698765432.", "GB82WEST12345698765432"},
+		{IBAN, "pay to DE89 3704 0044 0532 0130 00 today", "DE89 3704 0044 0532 0130 00"},
+		{IBAN, "FR14 2004 1010 0505 0001 3M02 606", "FR14 2004 1010 0505 0001 3M02 606"},
+		{IBAN, "NO9386011117947", "NO9386011117947"},
+		{IBAN, "GB82WEST12345698765433", ""},
+		{IBAN, "GB82WEST123456987654321", ""},
+		{IBAN, "GB82 WEST  1234 5698 7654 32", ""},
+		{IBAN, "ZZ82WEST12345698765432", ""},
+		{BIC, "SWIFT: DEUTDEFF, branch DEUTDEFF500", "DEUTDEFF"},
+		{BIC, "NWBKGB2L", "NWBKGB2L"},
+		{BIC, "SWIFT DEUTZZFF", ""},
+		{BIC, "BIC DEUTDEFO", ""},
+		{BIC, "DEUTDEFF", ""},
+		{BIC, "DATABASE REGISTER HOSTNAME SHUTDOWN PROPERTY CALLBACK TRUNCATE", ""},
+		{ABARouting, "routing 021000021 account", "021000021"},
+		{ABARouting, "ABA: 011000015", "011000015"},
+		{ABARouting, "RTN 021000022", ""},
+		{ABARouting, "RTN 000000000", ""},
+		{ABARouting, "RTN 991000015", ""},
+		{ABARouting, "order 123456780", ""},
+		{BitcoinAddress, "send to 1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2.", "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2"},
+		{BitcoinAddress, "3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy", "3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy"},
+		{BitcoinAddress, "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN3", ""},
+		{BitcoinAddress, "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4", "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4"},
+		{BitcoinAddress, "BC1QW508D6QEJXTDG4Y5R3ZARVARY0C5XW7KV8F3T4", "BC1QW508D6QEJXTDG4Y5R3ZARVARY0C5XW7KV8F3T4"},
+		{BitcoinAddress, "bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqzk5jj0", "bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqzk5jj0"},
+		{BitcoinAddress, "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t5", ""},
+		{BitcoinAddress, "bc1pw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4", ""},
+		{EthereumAddress, "to 0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed;", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"},
+		{EthereumAddress, "0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359", "0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359"},
+		{EthereumAddress, "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed", "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed"},
+		{EthereumAddress, "0x5AAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", ""},
+		{EthereumAddress, "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed00", ""},
+	}
+	for _, tCase := range tCases {
+		t.Run(tCase.dataType.String()+" "+tCase.input, func(t *testing.T) {
+			actual := ""
+			if findings := New(tCase.dataType).ScanValues(tCase.input); len(findings) > 0 {
+				actual = findings[0].Value
+			}
+			if actual != tCase.expected {
+				t.Errorf("%s: expected \"%s\" but got \"%s\"", tCase.input, tCase.expected, actual)
+			}
+		})
+	}
+}
+
+func TestValidIBAN(t *testing.T) {
+	tCases := []struct {
+		input    string
+		expected bool
+	}{
+		{"GB82WEST12345698765432", true},
+		{"DE89370400440532013000", true},
+		{"DE89370400440532013001", false},
+		{"DE8937040044053201300", false},
+		{"GB82west12345698765432", false},
+		{"XX82WEST12345698765432", false},
+		{"GB", false},
+	}
+	for _, tCase := range tCases {
+		t.Run(tCase.input, func(t *testing.T) {
+			actual := validIBAN(tCase.input)
+			if actual != tCase.expected {
+				t.Errorf("%s: expected %v but got %v", tCase.input, tCase.expected, actual)
+			}
+		})
+	}
+}
+
+func ExampleAnonymizer_ScanValues_finance() {
+	a := New(IBAN, BIC, EthereumAddress)
+	text := "IBAN DE89 3704 0044 0532 0130 00, BIC DEUTDEFF, wallet 0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"
+	for _, f := range a.ScanValues(text) {
+		fmt.Println(f.Type, f.Value)
+	}
+	// Output:
+	// IBAN DE89 3704 0044 0532 0130 00
+	// BIC DEUTDEFF
+	// EthereumAddress 0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed
+}
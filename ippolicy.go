@@ -0,0 +1,115 @@
+/*
+Anon (c) 2023 by Mikhail Kondrashin (mkondrashin@gmail.com)
+github.com/mpkondrashin/anon
+
+ippolicy.go
+
+Policy choosing classes of IP addresses to anonymize
+*/
+package anon
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"strings"
+)
+
+// ErrIPPolicy - will be returned wrapped for unknown IP policy names.
+var ErrIPPolicy = errors.New("unknown IP policy")
+
+// IPPolicy - classes of IP addresses detected as IP4 and IP6 to anonymize.
+type IPPolicy int
+
+const (
+	// AllIPs - anonymize all of the addresses.
+	AllIPs IPPolicy = iota
+	// PublicIPs - anonymize only global unicast addresses that are not private,
+	// shared (100.64.0.0/10) or documentation ones.
+	PublicIPs
+	// PrivateIPs - anonymize only private addresses (RFC 1918 and RFC 4193 ULA).
+	PrivateIPs
+	// NonLocalIPs - anonymize all of the addresses except loopback, link-local
+	// and documentation ones.
+	NonLocalIPs
+)
+
+// ipPolicyNames - names of policies used by String and ParseIPPolicy.
+var ipPolicyNames = []string{"all", "public", "private", "non-local"}
+
+// String - return name of the policy.
+func (p IPPolicy) String() string {
+	if p < 0 || int(p) >= len(ipPolicyNames) {
+		return fmt.Sprintf("IPPolicy(%d)", int(p))
+	}
+	return ipPolicyNames[p]
+}
+
+// ParseIPPolicy - return policy by its name: "all", "public", "private" or "non-local".
+func ParseIPPolicy(name string) (IPPolicy, error) {
+	for i, n := range ipPolicyNames {
+		if strings.EqualFold(name, n) {
+			return IPPolicy(i), nil
+		}
+	}
+	return AllIPs, fmt.Errorf("%w: %s", ErrIPPolicy, name)
+}
+
+var (
+	// documentationNetworks - ranges reserved for documentation (RFC 5737 and RFC 3849).
+	documentationNetworks = []netip.Prefix{
+		netip.MustParsePrefix("192.0.2.0/24"),
+		netip.MustParsePrefix("198.51.100.0/24"),
+		netip.MustParsePrefix("203.0.113.0/24"),
+		netip.MustParsePrefix("2001:db8::/32"),
+	}
+	// sharedNetwork - carrier-grade NAT range (RFC 6598).
+	sharedNetwork = netip.MustParsePrefix("100.64.0.0/10")
+)
+
+// isDocumentation - return true if addr belongs to the documentation range.
+func isDocumentation(addr netip.Addr) bool {
+	for _, network := range documentationNetworks {
+		if network.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// anonymizes - return true if address should be anonymized according to the policy.
+func (p IPPolicy) anonymizes(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	switch p {
+	case PublicIPs:
+		return addr.IsGlobalUnicast() && !addr.IsPrivate() &&
+			!sharedNetwork.Contains(addr) && !isDocumentation(addr)
+	case PrivateIPs:
+		return addr.IsPrivate()
+	case NonLocalIPs:
+		return !addr.IsLoopback() && !addr.IsLinkLocalUnicast() &&
+			!addr.IsLinkLocalMulticast() && !isDocumentation(addr)
+	}
+	return true
+}
+
+// skipsIP - return true if value detected as data is IP address that should not be
+// anonymized according to the policy.
+func (a *Anonymizer) skipsIP(data *confidentialData, value string) bool {
+	if a.ipPolicy == AllIPs || data.isCustom() || (data.dataType != IP4 && data.dataType != IP6) {
+		return false
+	}
+	addr, err := netip.ParseAddr(value)
+	if err != nil {
+		return false
+	}
+	return !a.ipPolicy.anonymizes(addr)
+}
+
+// SetIPPolicy - anonymize only given class of addresses detected as IP4 and IP6.
+// For example, with PublicIPs internal topology stays visible while customer
+// addresses are hidden. Default is AllIPs.
+func (a *Anonymizer) SetIPPolicy(policy IPPolicy) *Anonymizer {
+	a.ipPolicy = policy
+	return a
+}
@@ -0,0 +1,66 @@
+/*
+Anon (c) 2023 by Mikhail Kondrashin (mkondrashin@gmail.com)
+github.com/mpkondrashin/anon
+
+ippolicy_test.go
+
+IP policy testing functions
+*/
+package anon
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestIPPolicy(t *testing.T) {
+	addresses := []string{"8.8.8.8", "10.1.1.1", "192.168.1.1", "127.0.0.1", "169.254.1.1",
+		"192.0.2.1", "100.64.1.1", "2a00:1450::1", "fd00::1", "::1", "fe80::1", "2001:db8::1"}
+	type tCase struct {
+		policy IPPolicy
+		hidden []string
+	}
+	tCases := []tCase{
+		{AllIPs, addresses},
+		{PublicIPs, []string{"8.8.8.8", "2a00:1450::1"}},
+		{PrivateIPs, []string{"10.1.1.1", "192.168.1.1", "fd00::1"}},
+		{NonLocalIPs, []string{"8.8.8.8", "10.1.1.1", "192.168.1.1", "100.64.1.1", "2a00:1450::1", "fd00::1"}},
+	}
+	for _, tc := range tCases {
+		t.Run(tc.policy.String(), func(t *testing.T) {
+			a := New(IP4, IP6).SetIPPolicy(tc.policy)
+			hidden := make(map[string]bool)
+			for _, address := range tc.hidden {
+				hidden[address] = true
+			}
+			for _, address := range addresses {
+				actual := a.Anonymize(address) != address
+				if actual != hidden[address] {
+					t.Errorf("%s: hidden expected %v, but got %v", address, hidden[address], actual)
+				}
+			}
+		})
+	}
+}
+
+func TestParseIPPolicy(t *testing.T) {
+	for _, p := range []IPPolicy{AllIPs, PublicIPs, PrivateIPs, NonLocalIPs} {
+		parsed, err := ParseIPPolicy(p.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if parsed != p {
+			t.Errorf("expected %v, but got %v", p, parsed)
+		}
+	}
+	if _, err := ParseIPPolicy("some"); !errors.Is(err, ErrIPPolicy) {
+		t.Errorf("expected ErrIPPolicy, but got %v", err)
+	}
+}
+
+func ExampleAnonymizer_SetIPPolicy() {
+	a := New(IP4).SetSalt([]byte{}).SetIPPolicy(PublicIPs)
+	fmt.Println(a.Anonymize("10.10.1.1 -> 1.1.1.1"))
+	// Output: 10.10.1.1 -> IP:G5yiayQG0JlmbYyijKDnosW2Q2d
+}
@@ -0,0 +1,372 @@
+/*
+Anon (c) 2023 by Mikhail Kondrashin (mkondrashin@gmail.com)
+github.com/mpkondrashin/anon
+
+json.go
+
+Structured JSON documents anonymization.
+*/
+package anon
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ErrJSONPath - will be returned wrapped when parsing invalid JSON path.
+var ErrJSONPath = errors.New("invalid JSON path")
+
+// JSONAction - what to do with values matching JSON path.
+type JSONAction int
+
+const (
+	// JSONDetect - anonymize confidential data found in string values (default).
+	JSONDetect JSONAction = iota
+	// JSONHide - always hide value regardless of its content.
+	JSONHide
+	// JSONSkip - never anonymize value.
+	JSONSkip
+)
+
+// jsonSegment - one step of the JSON path.
+type jsonSegment struct {
+	key       string
+	index     int
+	anyKey    bool // .* or [*]
+	isIndex   bool // [n]
+	recursive bool // ..
+}
+
+// matches - return true if segment matches object key or array index.
+func (s jsonSegment) matches(step any) bool {
+	if s.anyKey {
+		return true
+	}
+	switch v := step.(type) {
+	case string:
+		return !s.isIndex && s.key == v
+	case int:
+		return s.isIndex && s.index == v
+	}
+	return false
+}
+
+// JSONPath - compiled JSONPath-like expression. Supported syntax: $ for the root,
+// .key or ['key'] for object member, [n] for array element, .* or [*] for any member
+// or element and ..key for recursive descent, e.g. $.users[*].email or $..password.
+type JSONPath struct {
+	path     string
+	segments []jsonSegment
+}
+
+// String - return source of the path.
+func (p *JSONPath) String() string {
+	return p.path
+}
+
+// ParseJSONPath - parse JSONPath-like expression.
+func ParseJSONPath(path string) (*JSONPath, error) {
+	if !strings.HasPrefix(path, "$") {
+		return nil, fmt.Errorf("%w: %s: should start with $", ErrJSONPath, path)
+	}
+	result := &JSONPath{path: path}
+	rest := path[1:]
+	for len(rest) > 0 {
+		var segment jsonSegment
+		switch {
+		case strings.HasPrefix(rest, ".."):
+			segment.recursive = true
+			rest = rest[2:]
+		case rest[0] == '.':
+			rest = rest[1:]
+		case rest[0] == '[':
+		default:
+			return nil, fmt.Errorf("%w: %s: unexpected %q", ErrJSONPath, path, rest[0])
+		}
+		if strings.HasPrefix(rest, "[") {
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("%w: %s: missing ]", ErrJSONPath, path)
+			}
+			inside := rest[1:end]
+			rest = rest[end+1:]
+			switch {
+			case inside == "*":
+				segment.anyKey = true
+			case len(inside) >= 2 && (inside[0] == '\'' || inside[0] == '"') && inside[len(inside)-1] == inside[0]:
+				segment.key = inside[1 : len(inside)-1]
+			default:
+				index, err := strconv.Atoi(inside)
+				if err != nil || index < 0 {
+					return nil, fmt.Errorf("%w: %s: wrong index %q", ErrJSONPath, path, inside)
+				}
+				segment.index = index
+				segment.isIndex = true
+			}
+		} else {
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			segment.key = rest[:end]
+			rest = rest[end:]
+			if segment.key == "" {
+				return nil, fmt.Errorf("%w: %s: empty key", ErrJSONPath, path)
+			}
+			if segment.key == "*" {
+				segment.key = ""
+				segment.anyKey = true
+			}
+		}
+		result.segments = append(result.segments, segment)
+	}
+	return result, nil
+}
+
+// MustParseJSONPath - parse JSONPath-like expression and panic if it is invalid.
+func MustParseJSONPath(path string) *JSONPath {
+	p, err := ParseJSONPath(path)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// Match - return true if path matches given sequence of object keys (strings)
+// and array indexes (ints).
+func (p *JSONPath) Match(steps []any) bool {
+	return matchSegments(p.segments, steps)
+}
+
+func matchSegments(segments []jsonSegment, steps []any) bool {
+	if len(segments) == 0 {
+		return len(steps) == 0
+	}
+	segment := segments[0]
+	if !segment.recursive {
+		return len(steps) > 0 && segment.matches(steps[0]) && matchSegments(segments[1:], steps[1:])
+	}
+	for i := range steps {
+		if segment.matches(steps[i]) && matchSegments(segments[1:], steps[i+1:]) {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonRule - action for values matching the path.
+type jsonRule struct {
+	path   *JSONPath
+	action JSONAction
+}
+
+// JSONAnonymizer - anonymizer of JSON documents. Only string values are checked
+// for confidential data, so document structure is never broken. Tokens are the same
+// as produced by Anonymizer for plain text.
+type JSONAnonymizer struct {
+	anonymizer *Anonymizer
+	rules      []jsonRule
+	keys       bool
+}
+
+// JSON - return new JSON documents anonymizer.
+func (a *Anonymizer) JSON() *JSONAnonymizer {
+	return &JSONAnonymizer{
+		anonymizer: a,
+	}
+}
+
+// AddRule - set action for all values matching path. If value matches several rules,
+// the rule matching the longest part of the value path wins, and if there are several
+// of them, the last added wins. Rule for object or array is applied to all of its
+// values.
+func (j *JSONAnonymizer) AddRule(action JSONAction, path string) error {
+	p, err := ParseJSONPath(path)
+	if err != nil {
+		return err
+	}
+	j.rules = append(j.rules, jsonRule{path: p, action: action})
+	return nil
+}
+
+// HidePaths - always hide values matching given paths. Panics if path is invalid.
+func (j *JSONAnonymizer) HidePaths(paths ...string) *JSONAnonymizer {
+	for _, path := range paths {
+		j.rules = append(j.rules, jsonRule{path: MustParseJSONPath(path), action: JSONHide})
+	}
+	return j
+}
+
+// SkipPaths - never anonymize values matching given paths. Panics if path is invalid.
+func (j *JSONAnonymizer) SkipPaths(paths ...string) *JSONAnonymizer {
+	for _, path := range paths {
+		j.rules = append(j.rules, jsonRule{path: MustParseJSONPath(path), action: JSONSkip})
+	}
+	return j
+}
+
+// AnonymizeKeys - check object keys for confidential data as well.
+func (j *JSONAnonymizer) AnonymizeKeys(anonymize bool) *JSONAnonymizer {
+	j.keys = anonymize
+	return j
+}
+
+// action - return action for the value with given path.
+func (j *JSONAnonymizer) action(steps []any) JSONAction {
+	action := JSONDetect
+	depth := -1
+	for _, rule := range j.rules {
+		for d := len(steps); d >= depth && d >= 0; d-- {
+			if rule.path.Match(steps[:d]) {
+				action = rule.action
+				depth = d
+				break
+			}
+		}
+	}
+	return action
+}
+
+// jsonFrame - object or array being processed.
+type jsonFrame struct {
+	object    bool
+	count     int
+	expectKey bool
+}
+
+// Anonymize - read JSON values from src and write anonymized compact JSON to dst.
+// Input is processed as a stream, so documents of any size can be anonymized.
+// Several top level values are separated by newline.
+func (j *JSONAnonymizer) Anonymize(dst io.Writer, src io.Reader) error {
+	decoder := json.NewDecoder(src)
+	decoder.UseNumber()
+	w := bufio.NewWriter(dst)
+	var stack []jsonFrame
+	var steps []any
+	topLevel := 0
+	// beforeValue - write separator before the value and add array index to the path
+	beforeValue := func() {
+		if len(stack) == 0 {
+			if topLevel > 0 {
+				w.WriteByte('\n')
+			}
+			topLevel++
+			return
+		}
+		top := &stack[len(stack)-1]
+		if !top.object {
+			if top.count > 0 {
+				w.WriteByte(',')
+			}
+			steps = append(steps, top.count)
+		}
+		top.count++
+	}
+	// afterValue - remove value key or index from the path
+	afterValue := func() {
+		if len(stack) == 0 {
+			return
+		}
+		top := &stack[len(stack)-1]
+		steps = steps[:len(steps)-1]
+		if top.object {
+			top.expectKey = true
+		}
+	}
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if delim, ok := token.(json.Delim); ok {
+			switch delim {
+			case '{', '[':
+				beforeValue()
+				w.WriteRune(rune(delim))
+				stack = append(stack, jsonFrame{object: delim == '{', expectKey: delim == '{'})
+			case '}', ']':
+				stack = stack[:len(stack)-1]
+				w.WriteRune(rune(delim))
+				afterValue()
+			}
+			continue
+		}
+		if len(stack) > 0 && stack[len(stack)-1].expectKey {
+			top := &stack[len(stack)-1]
+			key := token.(string)
+			if top.count > 0 {
+				w.WriteByte(',')
+			}
+			steps = append(steps, key)
+			if j.keys && j.action(steps) != JSONSkip {
+				key = j.anonymizer.Anonymize(key)
+			}
+			writeJSONString(w, key)
+			w.WriteByte(':')
+			top.expectKey = false
+			continue
+		}
+		beforeValue()
+		j.writeValue(w, token, j.action(steps))
+		afterValue()
+	}
+	if len(stack) > 0 {
+		return io.ErrUnexpectedEOF
+	}
+	return w.Flush()
+}
+
+// AnonymizeBytes - anonymize JSON document.
+func (j *JSONAnonymizer) AnonymizeBytes(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := j.Anonymize(&buf, bytes.NewReader(data)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeValue - write anonymized scalar value.
+func (j *JSONAnonymizer) writeValue(w *bufio.Writer, value any, action JSONAction) {
+	switch v := value.(type) {
+	case nil:
+		w.WriteString("null")
+	case string:
+		switch action {
+		case JSONHide:
+			v = j.anonymizer.Hide(v)
+		case JSONDetect:
+			v = j.anonymizer.Anonymize(v)
+		}
+		writeJSONString(w, v)
+	case json.Number:
+		if action == JSONHide {
+			writeJSONString(w, j.anonymizer.Hide(v.String()))
+			return
+		}
+		w.WriteString(v.String())
+	case bool:
+		if action == JSONHide {
+			writeJSONString(w, j.anonymizer.Hide(v))
+			return
+		}
+		w.WriteString(strconv.FormatBool(v))
+	}
+}
+
+// writeJSONString - write s as JSON string without escaping HTML characters.
+func writeJSONString(w io.Writer, s string) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	_ = encoder.Encode(s)
+	w.Write(bytes.TrimSuffix(buf.Bytes(), []byte{'\n'}))
+}
@@ -0,0 +1,117 @@
+/*
+Anon (c) 2023 by Mikhail Kondrashin (mkondrashin@gmail.com)
+github.com/mpkondrashin/anon
+
+json_test.go
+
+JSON anonymization testing functions
+*/
+package anon
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestJSONPath(t *testing.T) {
+	tCases := []struct {
+		path     string
+		steps    []any
+		expected bool
+	}{
+		{"$", []any{}, true},
+		{"$.a", []any{"a"}, true},
+		{"$.a", []any{"b"}, false},
+		{"$.a.b", []any{"a", "b"}, true},
+		{"$['a b'].c", []any{"a b", "c"}, true},
+		{"$.a[1]", []any{"a", 1}, true},
+		{"$.a[1]", []any{"a", 2}, false},
+		{"$.a[*].ip", []any{"a", 5, "ip"}, true},
+		{"$.*.ip", []any{"x", "ip"}, true},
+		{"$..password", []any{"a", 1, "b", "password"}, true},
+		{"$..password", []any{"password"}, true},
+		{"$..password", []any{"password", "x"}, false},
+	}
+	for _, tCase := range tCases {
+		t.Run(tCase.path, func(t *testing.T) {
+			actual := MustParseJSONPath(tCase.path).Match(tCase.steps)
+			if actual != tCase.expected {
+				t.Errorf("%s: %v: expected %v but got %v", tCase.path, tCase.steps, tCase.expected, actual)
+			}
+		})
+	}
+}
+
+func TestJSONPathInvalid(t *testing.T) {
+	for _, path := range []string{"a.b", "$.a[", "$.a[x]", "$.", "$a"} {
+		t.Run(path, func(t *testing.T) {
+			_, err := ParseJSONPath(path)
+			if !errors.Is(err, ErrJSONPath) {
+				t.Errorf("%s: expected ErrJSONPath, but got %v", path, err)
+			}
+		})
+	}
+}
+
+func TestJSONAnonymize(t *testing.T) {
+	a := New(Email, IP4).SetSalt([]byte{})
+	j := a.JSON().
+		HidePaths("$.user", "$..secret").
+		SkipPaths("$.user.public", "$.request_id").
+		AnonymizeKeys(true)
+	input := `{"host": "10.10.1.1", "user": {"id": 123, "public": "a@b.com", "name": "John"},
+"request_id": "10.10.1.2", "list": [{"secret": true}, "<c@d.com>"], "10.10.1.3": null}
+[1, 2]`
+	expected := `{"host":"IP:` + a.Token("10.10.1.1") + `","user":{"id":"` + a.Token("123") +
+		`","public":"a@b.com","name":"` + a.Token("John") + `"},"request_id":"10.10.1.2","list":[{"secret":"` +
+		a.Token("true") + `"},"<Email:` + a.Token("c@d.com") + `>"],"IP:` + a.Token("10.10.1.3") + `":null}` + "\n[1,2]"
+	actual, err := j.AnonymizeBytes([]byte(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(actual) != expected {
+		t.Errorf("expected\n%s\nbut got\n%s", expected, string(actual))
+	}
+}
+
+func TestJSONRuleOrder(t *testing.T) {
+	a := New(Email).SetSalt([]byte{})
+	input := `{"a": "x", "b": "y"}`
+	expected := `{"a":"x","b":"` + a.Token("y") + `"}`
+	actual, err := a.JSON().HidePaths("$.a", "$.b").SkipPaths("$.a").AnonymizeBytes([]byte(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(actual) != expected {
+		t.Errorf("expected %s, but got %s", expected, string(actual))
+	}
+	actual, err = a.JSON().SkipPaths("$.a").HidePaths("$.a").AnonymizeBytes([]byte(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := `{"a":"` + a.Token("x") + `","b":"y"}`; string(actual) != expected {
+		t.Errorf("expected %s, but got %s", expected, string(actual))
+	}
+}
+
+func TestJSONAnonymizeInvalid(t *testing.T) {
+	a := New(Email)
+	for _, input := range []string{`{"a":`, `{"a" 1}`, `[1,2`} {
+		t.Run(input, func(t *testing.T) {
+			if _, err := a.JSON().AnonymizeBytes([]byte(input)); err == nil {
+				t.Errorf("%s: expected error", input)
+			}
+		})
+	}
+}
+
+func ExampleJSONAnonymizer_Anonymize() {
+	a := New(IP4).SetSalt([]byte{})
+	j := a.JSON().HidePaths("$.user_id")
+	var sb strings.Builder
+	j.Anonymize(&sb, strings.NewReader(`{"user_id": 42, "address": "10.10.1.1"}`))
+	fmt.Println(sb.String())
+	// Output: {"user_id":"ls4KWoIINwq0q9Y1RgxMmvyUxuj","address":"IP:_M_jR4OvYA8NfVv3cruBTtC5U8R"}
+}
@@ -0,0 +1,206 @@
+/*
+Anon (c) 2023 by Mikhail Kondrashin (mkondrashin@gmail.com)
+github.com/mpkondrashin/anon
+
+keys.go
+
+Key providers: static keys, keys from environment and files, passphrase
+derived keys and scheduled rotation.
+*/
+package anon
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/crypto/scrypt"
+)
+
+// ErrKey - will be returned wrapped for missing or invalid keys.
+var ErrKey = errors.New("invalid key")
+
+// Key - secret key with its ID. ID is put into tokens produced with this key,
+// so it is known later which key produced the token. Key with empty ID
+// produces tokens without ID.
+type Key struct {
+	ID    string
+	Value []byte
+}
+
+// KeyProvider - source of keys.
+type KeyProvider interface {
+	// Key - return key to be used at given time.
+	Key(t time.Time) Key
+	// KeyByID - return key with given ID to process tokens produced earlier.
+	KeyByID(id string) (Key, error)
+}
+
+// staticKey - provider of the single key.
+type staticKey Key
+
+// StaticKey - return provider of the given key.
+func StaticKey(id string, key []byte) KeyProvider {
+	return staticKey{ID: id, Value: key}
+}
+
+// Key - return the key.
+func (k staticKey) Key(time.Time) Key {
+	return Key(k)
+}
+
+// KeyByID - return the key if ID matches.
+func (k staticKey) KeyByID(id string) (Key, error) {
+	if id != k.ID {
+		return Key{}, fmt.Errorf("%w: unknown key %q", ErrKeyID, id)
+	}
+	return Key(k), nil
+}
+
+// EnvKey - return provider of the hex encoded key stored in environment variable.
+func EnvKey(id, name string) (KeyProvider, error) {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return nil, fmt.Errorf("%w: environment variable %s is not set", ErrKey, name)
+	}
+	key, err := decodeKey(value)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return StaticKey(id, key), nil
+}
+
+// FileKey - return provider of the hex encoded key stored in file.
+func FileKey(id, path string) (KeyProvider, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrKey, err)
+	}
+	key, err := decodeKey(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return StaticKey(id, key), nil
+}
+
+// decodeKey - decode hex encoded key.
+func decodeKey(s string) ([]byte, error) {
+	key, err := hex.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrKey, err)
+	}
+	if len(key) == 0 {
+		return nil, fmt.Errorf("%w: empty key", ErrKey)
+	}
+	return key, nil
+}
+
+// KDF - key derivation function used to get key from passphrase.
+type KDF int
+
+const (
+	// Argon2id - Argon2id with 1 pass, 64 MiB of memory and 4 threads.
+	Argon2id KDF = iota
+	// Scrypt - scrypt with N=32768, r=8 and p=1.
+	Scrypt
+	// PBKDF2 - PBKDF2 with HMAC-SHA256 and 600000 iterations.
+	PBKDF2
+)
+
+// derivedKeyLength - length of the keys derived from passphrases and master keys.
+const derivedKeyLength = 32
+
+// DeriveKey - return 32 bytes key derived from passphrase. Salt should be unique
+// for the application, but does not need to be secret.
+func DeriveKey(passphrase string, salt []byte, kdf KDF) ([]byte, error) {
+	if passphrase == "" {
+		return nil, fmt.Errorf("%w: empty passphrase", ErrKey)
+	}
+	switch kdf {
+	case Argon2id:
+		return argon2.IDKey([]byte(passphrase), salt, 1, 64*1024, 4, derivedKeyLength), nil
+	case Scrypt:
+		return scrypt.Key([]byte(passphrase), salt, 32768, 8, 1, derivedKeyLength)
+	case PBKDF2:
+		return pbkdf2.Key([]byte(passphrase), salt, 600000, derivedKeyLength, sha256.New), nil
+	}
+	return nil, fmt.Errorf("%w: unknown KDF %d", ErrKey, kdf)
+}
+
+// PassphraseKey - return provider of the key derived from passphrase.
+func PassphraseKey(id, passphrase string, salt []byte, kdf KDF) (KeyProvider, error) {
+	key, err := DeriveKey(passphrase, salt, kdf)
+	if err != nil {
+		return nil, err
+	}
+	return StaticKey(id, key), nil
+}
+
+// rotationIDFormat - time format of the rotating key ID suffix.
+const rotationIDFormat = "20060102T1504"
+
+// rotatingKeys - provider of keys derived from master key for each period of time.
+type rotatingKeys struct {
+	id     string
+	master []byte
+	period time.Duration
+}
+
+// RotatingKeys - return provider of the keys changing every period (e.g. 24 * time.Hour
+// for daily keys). Keys are derived from master key and the beginning of the period,
+// so all of the processes sharing master key agree on them. Periods are aligned to
+// Unix epoch in UTC. Key ID is id followed by the beginning of the period, like
+// "k1-20231209T0000". Period should be a whole number of minutes.
+func RotatingKeys(id string, master []byte, period time.Duration) (KeyProvider, error) {
+	if period < time.Minute || period%time.Minute != 0 {
+		return nil, fmt.Errorf("%w: rotation period %v should be a whole number of minutes", ErrKey, period)
+	}
+	if len(master) == 0 {
+		return nil, fmt.Errorf("%w: empty master key", ErrKey)
+	}
+	if strings.ContainsAny(id, ".:") {
+		return nil, fmt.Errorf("%w: %q", ErrKeyID, id)
+	}
+	return &rotatingKeys{id: id, master: master, period: period}, nil
+}
+
+// Key - return key for the period containing t.
+func (r *rotatingKeys) Key(t time.Time) Key {
+	seconds := int64(r.period / time.Second)
+	index := t.Unix() / seconds
+	if t.Unix()%seconds < 0 {
+		index--
+	}
+	return r.key(time.Unix(index*seconds, 0).UTC())
+}
+
+// KeyByID - return key for the period encoded in ID.
+func (r *rotatingKeys) KeyByID(id string) (Key, error) {
+	prefix := r.id + "-"
+	if !strings.HasPrefix(id, prefix) {
+		return Key{}, fmt.Errorf("%w: unknown key %q", ErrKeyID, id)
+	}
+	start, err := time.Parse(rotationIDFormat, id[len(prefix):])
+	if err != nil {
+		return Key{}, fmt.Errorf("%w: %q: %v", ErrKeyID, id, err)
+	}
+	if r.Key(start).ID != id {
+		return Key{}, fmt.Errorf("%w: %q is not the beginning of the period", ErrKeyID, id)
+	}
+	return r.key(start), nil
+}
+
+// key - return key for the period starting at given time.
+func (r *rotatingKeys) key(start time.Time) Key {
+	id := r.id + "-" + start.Format(rotationIDFormat)
+	mac := hmac.New(sha256.New, r.master)
+	mac.Write([]byte("anon key " + id))
+	return Key{ID: id, Value: mac.Sum(nil)}
+}
@@ -0,0 +1,228 @@
+/*
+Anon (c) 2023 by Mikhail Kondrashin (mkondrashin@gmail.com)
+github.com/mpkondrashin/anon
+
+keys_test.go
+
+Key providers testing functions
+*/
+package anon
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRotatingKeys(t *testing.T) {
+	keys, err := RotatingKeys("k1", []byte("master"), 24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	type tCase struct {
+		t  time.Time
+		id string
+	}
+	tCases := []tCase{
+		{time.Date(2023, 12, 9, 0, 0, 0, 0, time.UTC), "k1-20231209T0000"},
+		{time.Date(2023, 12, 9, 23, 59, 59, 0, time.UTC), "k1-20231209T0000"},
+		{time.Date(2023, 12, 10, 0, 0, 0, 0, time.UTC), "k1-20231210T0000"},
+		{time.Date(1969, 12, 31, 12, 0, 0, 0, time.UTC), "k1-19691231T0000"},
+	}
+	for _, tc := range tCases {
+		t.Run(tc.t.String(), func(t *testing.T) {
+			key := keys.Key(tc.t)
+			if key.ID != tc.id {
+				t.Errorf("expected %s, but got %s", tc.id, key.ID)
+			}
+			byID, err := keys.KeyByID(key.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(byID.Value, key.Value) {
+				t.Errorf("KeyByID returned different key for %s", key.ID)
+			}
+		})
+	}
+	first := keys.Key(tCases[0].t)
+	second := keys.Key(tCases[2].t)
+	if bytes.Equal(first.Value, second.Value) {
+		t.Error("keys for different periods are equal")
+	}
+	for _, id := range []string{"k2-20231209T0000", "k1-20231209T0100", "k1-bad", "k1"} {
+		if _, err := keys.KeyByID(id); !errors.Is(err, ErrKeyID) {
+			t.Errorf("%s: expected ErrKeyID, but got %v", id, err)
+		}
+	}
+}
+
+func TestRotatingKeysErrors(t *testing.T) {
+	for _, period := range []time.Duration{0, time.Second, 90 * time.Second} {
+		if _, err := RotatingKeys("k", []byte("master"), period); !errors.Is(err, ErrKey) {
+			t.Errorf("%v: expected ErrKey, but got %v", period, err)
+		}
+	}
+	if _, err := RotatingKeys("k", nil, time.Hour); !errors.Is(err, ErrKey) {
+		t.Errorf("expected ErrKey, but got %v", err)
+	}
+	if _, err := RotatingKeys("k.1", []byte("master"), time.Hour); !errors.Is(err, ErrKeyID) {
+		t.Errorf("expected ErrKeyID, but got %v", err)
+	}
+}
+
+func TestFileAndEnvKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "key")
+	if err := os.WriteFile(path, []byte("00112233\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	keys, err := FileKey("f", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key := keys.Key(time.Now()); key.ID != "f" || !bytes.Equal(key.Value, []byte{0, 0x11, 0x22, 0x33}) {
+		t.Errorf("wrong key %v", key)
+	}
+	if _, err := FileKey("f", filepath.Join(t.TempDir(), "missing")); !errors.Is(err, ErrKey) {
+		t.Errorf("expected ErrKey, but got %v", err)
+	}
+	t.Setenv("ANON_TEST_KEY", "zz")
+	if _, err := EnvKey("e", "ANON_TEST_KEY"); !errors.Is(err, ErrKey) {
+		t.Errorf("expected ErrKey, but got %v", err)
+	}
+	t.Setenv("ANON_TEST_KEY", "0a0b")
+	keys, err = EnvKey("e", "ANON_TEST_KEY")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := keys.KeyByID("other"); !errors.Is(err, ErrKeyID) {
+		t.Errorf("expected ErrKeyID, but got %v", err)
+	}
+}
+
+func TestDeriveKey(t *testing.T) {
+	for _, kdf := range []KDF{Argon2id, Scrypt, PBKDF2} {
+		key, err := DeriveKey("passphrase", []byte("salt"), kdf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(key) != 32 {
+			t.Errorf("%d: expected 32 bytes, but got %d", kdf, len(key))
+		}
+		again, _ := DeriveKey("passphrase", []byte("salt"), kdf)
+		if !bytes.Equal(key, again) {
+			t.Errorf("%d: derivation is not deterministic", kdf)
+		}
+	}
+	if _, err := DeriveKey("", []byte("salt"), PBKDF2); !errors.Is(err, ErrKey) {
+		t.Errorf("expected ErrKey, but got %v", err)
+	}
+}
+
+func TestAnonymizerKeyProvider(t *testing.T) {
+	keys, err := RotatingKeys("k1", []byte("master"), time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2023, 12, 9, 10, 30, 0, 0, time.UTC)
+	a := New(IP4).SetKeyProvider(keys)
+	a.now = func() time.Time { return now }
+	first := a.Hide("10.10.1.1")
+	if !strings.HasPrefix(first, "IP:k1-20231209T1000.") {
+		t.Errorf("token without key ID: %s", first)
+	}
+	if id := TokenKeyID(first); id != "k1-20231209T1000" {
+		t.Errorf("wrong key ID %s", id)
+	}
+	now = now.Add(time.Hour)
+	second := a.Hide("10.10.1.1")
+	if first == second {
+		t.Errorf("same token for different keys: %s", first)
+	}
+	if id := TokenKeyID("IP:_M_jR4OvYA8NfVv3cruBTtC5U8R"); id != "" {
+		t.Errorf("expected no key ID, but got %s", id)
+	}
+}
+
+func TestKeyProviderEncryptor(t *testing.T) {
+	keys, err := RotatingKeys("k1", []byte("master"), time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, err := NewKeyProviderEncryptor(keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2023, 12, 9, 10, 30, 0, 0, time.UTC)
+	e.now = func() time.Time { return now }
+	token := e.Encrypt("john@example.com")
+	now = now.Add(time.Hour)
+	rotated := e.Encrypt("john@example.com")
+	if token == rotated {
+		t.Errorf("same token for different keys: %s", token)
+	}
+	fresh, err := NewKeyProviderEncryptor(keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tk := range []string{token, rotated} {
+		value, err := fresh.Decrypt(tk)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if value != "john@example.com" {
+			t.Errorf("expected john@example.com, but got %s", value)
+		}
+	}
+	if _, err := NewKeyProviderEncryptor(StaticKey("", make([]byte, 32))); !errors.Is(err, ErrKeyID) {
+		t.Errorf("expected ErrKeyID, but got %v", err)
+	}
+	if _, err := NewKeyProviderEncryptor(StaticKey("k1", make([]byte, 20))); !errors.Is(err, ErrKey) {
+		t.Errorf("expected ErrKey, but got %v", err)
+	}
+}
+
+// badKeys - key provider returning key of wrong length after the first one.
+type badKeys struct {
+	calls int
+}
+
+func (k *badKeys) Key(time.Time) Key {
+	k.calls++
+	if k.calls > 1 {
+		return Key{ID: "bad", Value: make([]byte, 20)}
+	}
+	return Key{ID: "good", Value: make([]byte, 32)}
+}
+
+func (k *badKeys) KeyByID(id string) (Key, error) {
+	return Key{}, fmt.Errorf("%w: %s", ErrKeyID, id)
+}
+
+func TestKeyProviderEncryptorBadKey(t *testing.T) {
+	e, err := NewKeyProviderEncryptor(&badKeys{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := e.EncryptE("secret"); !errors.Is(err, ErrKey) {
+		t.Errorf("expected ErrKey, but got %v", err)
+	}
+	output := New(IP4).SetStrategy(e).Anonymize("from 10.10.1.1")
+	if output != "from IP:" {
+		t.Errorf("unexpected output %s", output)
+	}
+	if !errors.Is(e.Err(), ErrKey) {
+		t.Errorf("expected ErrKey, but got %v", e.Err())
+	}
+}
+
+func ExampleAnonymizer_SetKeyProvider() {
+	a := New(IP4).SetKeyProvider(StaticKey("k1", []byte("secret")))
+	token := a.Hide("10.10.1.1")
+	fmt.Println(TokenKeyID(token))
+	// Output: k1
+}
@@ -0,0 +1,121 @@
+/*
+Anon (c) 2023 by Mikhail Kondrashin (mkondrashin@gmail.com)
+github.com/mpkondrashin/anon
+
+keyvalue.go
+
+Detector of values of sensitive keys like "password=hunter2"
+*/
+package anon
+
+import (
+	"regexp"
+	"strings"
+)
+
+// DefaultSecretKeys - key names values of which are detected as KeyValueSecret.
+// Key matches if it ends with one of them, so "db_password" and "X-Auth-Token" match too.
+var DefaultSecretKeys = []string{"password", "passwd", "pwd", "passphrase", "secret",
+	"token", "apikey", "api_key", "api-key", "access_key", "secret_key", "private_key",
+	"authorization", "credentials", "cookie", "sessionid"}
+
+// Value forms: double quoted, single quoted and unquoted. Groups hold value without quotes.
+const (
+	kvDoubleQuoted = `"((?:[^"\\]|\\.)*)"`
+	kvSingleQuoted = `'([^']*)'`
+	// kvUnquotedEqual - value after '=' ends at whitespace or separator of the query string.
+	kvUnquotedEqual = `([^\s&;,"']+)`
+	// kvUnquotedColon - value after ':' (YAML, HTTP headers) spans till the end of the line,
+	// quote or bracket. Structured values (starting with '{' or '[') are skipped, as their
+	// own keys are checked.
+	kvUnquotedColon = `([^\s,{}\[\]"'](?:[^\r\n,{}\[\]"']*[^\s,{}\[\]"'])?)`
+	// kvUnquotedFlag - command line flag value.
+	kvUnquotedFlag = `([^\s"'-]\S*)`
+)
+
+// kvColonGroup - number of the group holding unquoted value after ':'.
+const kvColonGroup = 6
+
+// rxNextField - beginning of the next key=value field in the value after ':'.
+var rxNextField = regexp.MustCompile(`\s+[A-Za-z_][A-Za-z0-9_.-]*=`)
+
+// keyValueDetector - KeyValueSecret detector for the list of keys.
+type keyValueDetector struct {
+	regex *regexp.Regexp
+}
+
+// defaultKeyValueDetector - detector used if keys are not set.
+var defaultKeyValueDetector = newKeyValueDetector(DefaultSecretKeys)
+
+// newKeyValueDetector - return detector for given keys. Following forms are recognized:
+// key=value (logfmt, query strings, --key=value flags), "key": "value" (JSON),
+// key: value (YAML, HTTP headers) and --key value (command line flags).
+func newKeyValueDetector(keys []string) *keyValueDetector {
+	if len(keys) == 0 {
+		return &keyValueDetector{}
+	}
+	quoted := make([]string, len(keys))
+	for i, k := range keys {
+		quoted[i] = regexp.QuoteMeta(k)
+	}
+	key := `[A-Za-z0-9_.-]*(?:` + strings.Join(quoted, "|") + `)`
+	value := func(unquoted string) string {
+		return `(?:` + kvDoubleQuoted + `|` + kvSingleQuoted + `|` + unquoted + `)`
+	}
+	forms := []string{
+		`\b` + key + `["']?\s*=\s*` + value(kvUnquotedEqual),
+		`\b` + key + `["']?\s*:\s*` + value(kvUnquotedColon),
+		`(?:^|\s)--?` + key + `\s+` + value(kvUnquotedFlag),
+	}
+	return &keyValueDetector{regex: mustCompileLongest(`(?i)(?:` + strings.Join(forms, "|") + `)`)}
+}
+
+// SetSecretKeys - set list of keys values of which are detected as KeyValueSecret
+// instead of DefaultSecretKeys. Case of keys is ignored.
+func (a *Anonymizer) SetSecretKeys(keys ...string) *Anonymizer {
+	a.keyValue = newKeyValueDetector(keys)
+	return a
+}
+
+// findKeyValueSecrets - return locations of the values of sensitive keys in input.
+func findKeyValueSecrets(a *Anonymizer, input string) [][]int {
+	d := a.keyValue
+	if d == nil {
+		d = defaultKeyValueDetector
+	}
+	if d.regex == nil {
+		return nil
+	}
+	var result [][]int
+	tokens := a.tokens(input)
+	for _, m := range d.regex.FindAllStringSubmatchIndex(input, -1) {
+		for g := 2; g < len(m); g += 2 {
+			if m[g] < 0 {
+				continue
+			}
+			loc := []int{m[g], m[g+1]}
+			if g == 2*kvColonGroup {
+				loc[1] = loc[0] + colonValueLength(input[loc[0]:loc[1]])
+			}
+			if !isToken(tokens, loc[0], loc[1]) {
+				result = append(result, loc)
+			}
+			break
+		}
+	}
+	return result
+}
+
+// colonValueLength - return length of the value after ':' cutting it before the next
+// key=value field, like in "token: expired abc user=john". Value that is a list of
+// key=value pairs itself (like Cookie header) is kept whole.
+func colonValueLength(value string) int {
+	first, _, _ := strings.Cut(value, " ")
+	if strings.Contains(first, "=") {
+		return len(value)
+	}
+	if loc := rxNextField.FindStringIndex(value); loc != nil {
+		return loc[0]
+	}
+	return len(value)
+}
@@ -0,0 +1,91 @@
+/*
+Anon (c) 2023 by Mikhail Kondrashin (mkondrashin@gmail.com)
+github.com/mpkondrashin/anon
+
+keyvalue_test.go
+
+Sensitive keys detector testing functions
+*/
+package anon
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestKeyValueSecret(t *testing.T) {
+	tCases := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"logfmt", "level=info password=hunter2 user=john", "level=info password=*** user=john"},
+		{"logfmt quoted", `db_password="hunter 2" ok`, `db_password="***" ok`},
+		{"json", `{"api_key": "abc", "name": "x"}`, `{"api_key": "***", "name": "x"}`},
+		{"json escaped", `{"secret": "a\"b"}`, `{"secret": "***"}`},
+		{"yaml", "db:\n  password: hunter 2\n  host: db", "db:\n  password: ***\n  host: db"},
+		{"yaml single quoted", "token: 'abc'", "token: '***'"},
+		{"query", "GET /login?user=john&passwd=hunter2&next=/", "GET /login?user=john&passwd=***&next=/"},
+		{"header", "X-Auth-Token: abc123\r\nHost: x", "X-Auth-Token: ***\r\nHost: x"},
+		{"cookie", "Cookie: sid=abc; theme=dark", "Cookie: ***"},
+		{"flag", "app --token abc --verbose", "app --token *** --verbose"},
+		{"flag equal", "app --password=abc", "app --password=***"},
+		{"case", "PASSWORD=abc", "PASSWORD=***"},
+		{"not suffix", "tokenizer=bert author=john", "tokenizer=bert author=john"},
+		{"empty", `password="" pwd=`, `password="" pwd=`},
+		{"flag without value", "app --token --verbose", "app --token --verbose"},
+		{"header in quotes", `curl -H "Authorization: Bearer abc" -u user:pass`, `curl -H "Authorization: ***" -u user:pass`},
+		{"nested", `{"token": {"value": "abc", "exp": 1}}`, `{"token": {"value": "abc", "exp": 1}}`},
+		{"next field", "token: expired abc user=john ip=1.2.3.4", "token: *** user=john ip=1.2.3.4"},
+		{"status", "auth: failed for user=john", "auth: failed for user=john"},
+		{"session status", "session: started", "session: started"},
+	}
+	a := New(KeyValueSecret).SetStrategy(Redact("***"))
+	for _, tCase := range tCases {
+		t.Run(tCase.name, func(t *testing.T) {
+			actual := a.Anonymize(tCase.input)
+			if actual != tCase.expected {
+				t.Errorf("%s: expected \"%s\", but got \"%s\"", tCase.input, tCase.expected, actual)
+			}
+		})
+	}
+}
+
+func TestSetSecretKeys(t *testing.T) {
+	a := New(KeyValueSecret).SetStrategy(Redact("***")).SetSecretKeys("pin")
+	expected := "card_pin=*** password=x"
+	if actual := a.Anonymize("card_pin=1234 password=x"); actual != expected {
+		t.Errorf("expected \"%s\", but got \"%s\"", expected, actual)
+	}
+	a.SetSecretKeys()
+	if actual := a.Anonymize("pin=1234"); actual != "pin=1234" {
+		t.Errorf("expected no changes, but got \"%s\"", actual)
+	}
+}
+
+func TestKeyValueSecretTokens(t *testing.T) {
+	a := New(KeyValueSecret)
+	once := a.Anonymize("password=hunter2")
+	if twice := a.Anonymize(once); twice != once {
+		t.Errorf("token anonymized again: \"%s\" -> \"%s\"", once, twice)
+	}
+	for _, tCase := range []struct {
+		a     *Anonymizer
+		input string
+	}{
+		{New(KeyValueSecret).SetTemplate("{token}"), "password=hunter2"},
+		{New(KeyValueSecret, Email), "password=Email:hunter2"},
+		{New(KeyValueSecret).SetTemplate("<{prefix} {token}>"), `password="<Value hunter2>"`},
+	} {
+		if actual := tCase.a.Anonymize(tCase.input); strings.Contains(actual, "hunter2") {
+			t.Errorf("%s: secret is not anonymized: \"%s\"", tCase.input, actual)
+		}
+	}
+}
+
+func ExampleAnonymizer_SetSecretKeys() {
+	a := New(KeyValueSecret).SetStrategy(Redact("[REDACTED]")).SetSecretKeys("password", "pin")
+	fmt.Println(a.Anonymize(`{"user": "john", "password": "hunter2", "pin": "1234"}`))
+	// Output: {"user": "john", "password": "[REDACTED]", "pin": "[REDACTED]"}
+}
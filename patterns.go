@@ -35,6 +35,33 @@ const (
 	PatternE164         string = `\+?[1-9]\d{1,14}`
 )
 
+// Regular expressions for credentials. Value is the whole match or the first group if
+// key name should be kept visible.
+const (
+	PatternAWSAccessKey string = `\b(?:AKIA|ASIA|ABIA|ACCA)[A-Z2-7]{16}\b`
+	PatternAWSSecretKey string = `(?i:aws_?secret_?(?:access_?)?key)["']?\s*[:=]\s*["']?([A-Za-z0-9/+]{40})(?:[^A-Za-z0-9/+]|$)`
+	PatternGitHubToken  string = `\b(?:gh[pousr]_[A-Za-z0-9]{36}|github_pat_[A-Za-z0-9]{22}_[A-Za-z0-9]{59})\b`
+	PatternGitLabToken  string = `\bgl(?:pat|dt|rt|ptt|ft|soat)-[A-Za-z0-9_-]{20,}`
+	PatternSlackToken   string = `\bxox[abposre]-[A-Za-z0-9-]{10,}`
+	PatternGoogleAPIKey string = `\b(AIza[A-Za-z0-9_-]{35})(?:[^A-Za-z0-9_-]|$)`
+	PatternStripeKey    string = `\b(?:sk|pk|rk)_(?:live|test)_[A-Za-z0-9]{24,}`
+	PatternJWT          string = `\beyJ[A-Za-z0-9_-]+\.eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`
+	PatternBearerToken  string = `(?i:authorization)["']?\s*[:=]\s*["']?(?i:bearer)\s+([A-Za-z0-9._~+/-]+=*)`
+	PatternPrivateKey   string = `-----BEGIN (?:[A-Z0-9]+ )*PRIVATE KEY-----(?:(?:[A-Za-z0-9+/=\s:,\\]|-[^-])*-----END (?:[A-Z0-9]+ )*PRIVATE KEY-----)?`
+)
+
+// Regular expressions for financial identifiers. IBAN is found by its beginning
+// (country code and check digits), as its length depends on the country.
+// BIC and ABA routing number patterns match many ordinary words and numbers,
+// so their matches are reported only in context (see findBIC and findABARouting).
+const (
+	PatternIBANStart       string = `\b[A-Z]{2}[0-9]{2}`
+	PatternBIC             string = `\b[A-Z]{6}[A-Z0-9]{2}(?:[A-Z0-9]{3})?\b`
+	PatternABARouting      string = `\b[0-9]{9}\b`
+	PatternBitcoinAddress  string = `\b(?:[13mn2][1-9A-HJ-NP-Za-km-z]{25,34}|(?:bc|tb)1[02-9ac-hj-np-z]{11,71}|(?:BC|TB)1[02-9AC-HJ-NP-Z]{11,71})\b`
+	PatternEthereumAddress string = `\b0x[0-9a-fA-F]{40}\b`
+)
+
 var (
 	ipv6Blocks = []string{
 		`([0-9a-fA-F]{1,4}:){7,7}[0-9a-fA-F]{1,4}`,         // 1:2:3:4:5:6:7:8
@@ -55,44 +82,161 @@ var (
 )
 
 var (
-	rxEmail      = regexp.MustCompile(PatternEmail)
-	rxCreditCard = regexp.MustCompile(PatternCreditCard)
-	rxUUID3      = regexp.MustCompile(PatternUUID3)
-	rxUUID4      = regexp.MustCompile(PatternUUID4)
-	rxUUID5      = regexp.MustCompile(PatternUUID5)
-	rxUUID       = regexp.MustCompile(PatternUUID)
-	rxLatitude   = regexp.MustCompile(PatternLatitude)
-	rxLongitude  = regexp.MustCompile(PatternLongitude)
-	rxIPv6       = regexp.MustCompile(ipv6RegexPattern) //PatternIP)
-	rxIPv4       = regexp.MustCompile(URLIP)
-	rxDNSName    = regexp.MustCompile(PatternDNSName)
-	rxURL        = regexp.MustCompile(PatternURL)
-	rxSSN        = regexp.MustCompile(PatternSSN)
-	rxIMEI       = regexp.MustCompile(PatternIMEI)
-	rxIMSI       = regexp.MustCompile(PatternIMSI)
-	rxE164       = regexp.MustCompile(PatternE164)
+	rxEmail      = mustCompileLongest(PatternEmail)
+	rxCreditCard = mustCompileLongest(PatternCreditCard)
+	rxUUID3      = mustCompileLongest(PatternUUID3)
+	rxUUID4      = mustCompileLongest(PatternUUID4)
+	rxUUID5      = mustCompileLongest(PatternUUID5)
+	rxUUID       = mustCompileLongest(PatternUUID)
+	rxLatitude   = mustCompileLongest(PatternLatitude)
+	rxLongitude  = mustCompileLongest(PatternLongitude)
+	rxIPv6       = mustCompileLongest(ipv6RegexPattern) //PatternIP)
+	rxIPv4       = mustCompileLongest(URLIP)
+	rxDNSName    = mustCompileLongest(PatternDNSName)
+	rxURL        = mustCompileLongest(PatternURL)
+	rxSSN        = mustCompileLongest(PatternSSN)
+	rxIMEI       = mustCompileLongest(PatternIMEI)
+	rxIMSI       = mustCompileLongest(PatternIMSI)
+	rxE164       = mustCompileLongest(PatternE164)
+
+	rxAWSAccessKey = mustCompileLongest(PatternAWSAccessKey)
+	rxAWSSecretKey = mustCompileLongest(PatternAWSSecretKey)
+	rxGitHubToken  = mustCompileLongest(PatternGitHubToken)
+	rxGitLabToken  = mustCompileLongest(PatternGitLabToken)
+	rxSlackToken   = mustCompileLongest(PatternSlackToken)
+	rxGoogleAPIKey = mustCompileLongest(PatternGoogleAPIKey)
+	rxStripeKey    = mustCompileLongest(PatternStripeKey)
+	rxJWT          = mustCompileLongest(PatternJWT)
+	rxBearerToken  = mustCompileLongest(PatternBearerToken)
+	rxPrivateKey   = mustCompileLongest(PatternPrivateKey)
+
+	rxIBANStart       = mustCompileLongest(PatternIBANStart)
+	rxBIC             = mustCompileLongest(PatternBIC)
+	rxABARouting      = mustCompileLongest(PatternABARouting)
+	rxBitcoinAddress  = mustCompileLongest(PatternBitcoinAddress)
+	rxEthereumAddress = mustCompileLongest(PatternEthereumAddress)
 )
 
+// mustCompileLongest - compile regex that prefers leftmost-longest matches,
+// so alternations do not stop on the shortest variant (e.g. "1.1.1.1" instead of "1.1.1.100").
+func mustCompileLongest(pattern string) *regexp.Regexp {
+	rx := regexp.MustCompile(pattern)
+	rx.Longest()
+	return rx
+}
+
+// findNumbers - return detector of rx matches that have no digit right before or after
+// them, so numbers are not taken from the middle of longer ones like order numbers.
+func findNumbers(rx *regexp.Regexp) func(*Anonymizer, string) [][]int {
+	return func(_ *Anonymizer, input string) [][]int {
+		var result [][]int
+		for _, loc := range rx.FindAllStringIndex(input, -1) {
+			if loc[0] > 0 && isDigit(input[loc[0]-1]) || loc[1] < len(input) && isDigit(input[loc[1]]) {
+				continue
+			}
+			result = append(result, loc)
+		}
+		return result
+	}
+}
+
+// isDigit - return true for ASCII digits.
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// emailSeparators - characters allowed in the local part of email, but used as separators
+// in URLs and key=value pairs much more often, like in "?email=john@example.com".
+const emailSeparators = "/?=&"
+
+// findEmails - return locations of emails in input. Local part is cut after the last
+// of emailSeparators.
+func findEmails(_ *Anonymizer, input string) [][]int {
+	locs := rxEmail.FindAllStringIndex(input, -1)
+	for _, loc := range locs {
+		at := strings.LastIndexByte(input[loc[0]:loc[1]], '@')
+		if i := strings.LastIndexAny(input[loc[0]:loc[0]+at], emailSeparators); i >= 0 {
+			loc[0] += i + 1
+		}
+	}
+	return locs
+}
+
 type confidentialData struct {
-	prefix string
-	regex  *regexp.Regexp
+	prefix   string
+	regex    *regexp.Regexp
+	validate validator
+	group    int                                       // regex group holding the value, 0 for the whole match
+	detect   func(a *Anonymizer, input string) [][]int // used instead of regex if set
+	dataType DataType
+	priority int
+	strategy Strategy
+}
+
+// isCustom - return true for data added by AddConfidentialData.
+func (c *confidentialData) isCustom() bool {
+	return c.priority >= customPriority
+}
+
+// find - return locations of all valid non empty values of this type in input.
+// Anonymizer is passed to detectors that have settings.
+func (c *confidentialData) find(a *Anonymizer, input string) (result [][]int) {
+	var locs [][]int
+	if c.detect != nil {
+		locs = c.detect(a, input)
+	} else if c.group == 0 {
+		locs = c.regex.FindAllStringIndex(input, -1)
+	} else {
+		for _, m := range c.regex.FindAllStringSubmatchIndex(input, -1) {
+			locs = append(locs, m[2*c.group:2*c.group+2])
+		}
+	}
+	for _, loc := range locs {
+		if loc[0] < 0 || loc[0] == loc[1] {
+			continue
+		}
+		if c.validate != nil && !c.validate(input[loc[0]:loc[1]]) {
+			continue
+		}
+		result = append(result, loc)
+	}
+	return
 }
 
 var confidentailData = map[DataType]confidentialData{
-	Email:      {"Email", rxEmail},
-	CreditCard: {"CreditCard", rxCreditCard},
-	UUID3:      {"UUID3", rxUUID3},
-	UUID4:      {"UUID4", rxUUID4},
-	UUID5:      {"UUID5", rxUUID5},
-	UUID:       {"UUID", rxUUID},
-	Latitude:   {"Latidude", rxLatitude},
-	Longitude:  {"Longitude", rxLongitude},
-	IP4:        {"IP", rxIPv4},
-	IP6:        {"IP6", rxIPv6},
-	DNSName:    {"DNS", rxDNSName},
-	URL:        {"URL", rxURL},
-	SSN:        {"SSN", rxSSN},
-	IMEI:       {"IMEI", rxIMEI},
-	IMSI:       {"IMSI", rxIMSI},
-	E164:       {"E162", rxE164},
+	Email:      {prefix: "Email", detect: findEmails},
+	CreditCard: {prefix: "CreditCard", detect: findNumbers(rxCreditCard), validate: validCreditCard},
+	UUID3:      {prefix: "UUID3", regex: rxUUID3, validate: validUUID},
+	UUID4:      {prefix: "UUID4", regex: rxUUID4, validate: validUUID},
+	UUID5:      {prefix: "UUID5", regex: rxUUID5, validate: validUUID},
+	UUID:       {prefix: "UUID", regex: rxUUID, validate: validUUID},
+	Latitude:   {prefix: "Latidude", regex: rxLatitude},
+	Longitude:  {prefix: "Longitude", regex: rxLongitude},
+	IP4:        {prefix: "IP", regex: rxIPv4, validate: validIPv4},
+	IP6:        {prefix: "IP6", regex: rxIPv6, validate: validIPv6},
+	DNSName:    {prefix: "DNS", regex: rxDNSName},
+	URL:        {prefix: "URL", regex: rxURL},
+	SSN:        {prefix: "SSN", detect: findNumbers(rxSSN), validate: validSSN},
+	IMEI:       {prefix: "IMEI", regex: rxIMEI, validate: validIMEI},
+	IMSI:       {prefix: "IMSI", regex: rxIMSI},
+	E164:       {prefix: "E162", regex: rxE164},
+
+	AWSAccessKey:   {prefix: "AWSKey", regex: rxAWSAccessKey, validate: validSecret},
+	AWSSecretKey:   {prefix: "AWSSecret", regex: rxAWSSecretKey, validate: validSecret, group: 1},
+	GitHubToken:    {prefix: "GitHub", regex: rxGitHubToken, validate: validSecret},
+	GitLabToken:    {prefix: "GitLab", regex: rxGitLabToken, validate: validSecret},
+	SlackToken:     {prefix: "Slack", regex: rxSlackToken, validate: validSecret},
+	GoogleAPIKey:   {prefix: "GoogleKey", regex: rxGoogleAPIKey, validate: validSecret, group: 1},
+	StripeKey:      {prefix: "Stripe", regex: rxStripeKey, validate: validSecret},
+	JWT:            {prefix: "JWT", regex: rxJWT, validate: validJWT},
+	BearerToken:    {prefix: "Bearer", regex: rxBearerToken, validate: validSecret, group: 1},
+	PrivateKey:     {prefix: "PrivateKey", regex: rxPrivateKey, validate: validPrivateKey},
+	HighEntropy:    {prefix: "Secret", detect: findHighEntropy},
+	KeyValueSecret: {prefix: "Value", detect: findKeyValueSecrets},
+
+	IBAN:            {prefix: "IBAN", detect: findIBAN},
+	BIC:             {prefix: "BIC", detect: findBIC, validate: validBIC},
+	ABARouting:      {prefix: "ABA", detect: findABARouting, validate: validABARouting},
+	BitcoinAddress:  {prefix: "BTC", regex: rxBitcoinAddress, validate: validBitcoinAddress},
+	EthereumAddress: {prefix: "ETH", regex: rxEthereumAddress, validate: validEthereumAddress},
 }
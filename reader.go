@@ -0,0 +1,70 @@
+/*
+Anon (c) 2023 by Mikhail Kondrashin (mkondrashin@gmail.com)
+github.com/mpkondrashin/anon
+
+reader.go
+
+Anonymizing reader.
+*/
+package anon
+
+import "io"
+
+// readChunkSize - size of the data read from the source at once.
+const readChunkSize = 4096
+
+// reader - io.Reader comply struct that anonymizes all of the data read from source.
+// Data is anonymized line by line, so values split between source reads are still
+// anonymized, while memory usage is bounded by the maximum line length.
+type reader struct {
+	anonymyzer *Anonymizer
+	source     io.Reader
+	input      []byte
+	output     []byte
+	err        error
+	keys       keyGuard
+}
+
+// Reader - return new io.Reader that anonymizes data read from source.
+func (a *Anonymizer) Reader(source io.Reader) io.Reader {
+	return &reader{
+		anonymyzer: a,
+		source:     source,
+	}
+}
+
+// Read - read anonymized data.
+func (r *reader) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	for len(r.output) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		r.fill()
+	}
+	n := copy(p, r.output)
+	r.output = r.output[n:]
+	return n, nil
+}
+
+// fill - read next chunk from source and anonymize all complete lines.
+func (r *reader) fill() {
+	start := len(r.input)
+	if cap(r.input)-start < readChunkSize {
+		r.input = append(r.input, make([]byte, readChunkSize)...)[:start]
+	}
+	n, err := r.source.Read(r.input[start : start+readChunkSize])
+	r.input = r.input[:start+n]
+	r.err = err
+	cut := len(r.input)
+	if err == nil {
+		cut = r.keys.cut(r.anonymyzer, r.input, r.anonymyzer.lineLength())
+	}
+	if cut == 0 {
+		return
+	}
+	r.output = append(r.output[:0], r.keys.anonymize(r.anonymyzer, string(r.input[:cut]), r.anonymyzer.lineLength())...)
+	r.input = r.input[:copy(r.input, r.input[cut:])]
+}
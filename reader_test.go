@@ -0,0 +1,85 @@
+/*
+Anon (c) 2023 by Mikhail Kondrashin (mkondrashin@gmail.com)
+github.com/mpkondrashin/anon
+
+reader_test.go
+
+Reader testing functions
+*/
+package anon
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestReader(t *testing.T) {
+	a := New(Email, IP4).SetSalt([]byte{})
+	input := strings.Repeat("mail john@example.com from 10.10.1.1\n", 500) + "last line 10.10.1.1"
+	expected := a.Anonymize(input)
+	tCases := []struct {
+		name   string
+		source io.Reader
+	}{
+		{"plain", strings.NewReader(input)},
+		{"one byte", iotest.OneByteReader(strings.NewReader(input))},
+		{"half", iotest.HalfReader(strings.NewReader(input))},
+		{"data err", iotest.DataErrReader(strings.NewReader(input))},
+	}
+	for _, tCase := range tCases {
+		t.Run(tCase.name, func(t *testing.T) {
+			data, err := io.ReadAll(a.Reader(tCase.source))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != expected {
+				t.Errorf("unexpected output: %s", string(data))
+			}
+		})
+	}
+}
+
+func TestReaderLongLine(t *testing.T) {
+	a := New(IP4).SetSalt([]byte{})
+	input := strings.Repeat("10.10.1.1 ", DefaultMaxLineLength/5)
+	data, err := io.ReadAll(a.Reader(iotest.HalfReader(strings.NewReader(input))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != a.Anonymize(input) {
+		t.Errorf("long line is not anonymized correctly")
+	}
+}
+
+func TestReaderPrivateKey(t *testing.T) {
+	a := New(PrivateKey).SetSalt([]byte{})
+	input := "key:\n" + testPEM + "\ndone\n"
+	data, err := io.ReadAll(a.Reader(iotest.OneByteReader(strings.NewReader(input))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := a.Anonymize(input); string(data) != expected {
+		t.Errorf("expected \"%s\", but got \"%s\"", expected, string(data))
+	}
+	data, _ = io.ReadAll(a.Reader(strings.NewReader("key:\n" + testPEM[:80])))
+	if strings.Contains(string(data), "MIIBOg") {
+		t.Errorf("incomplete private key is leaked: %s", string(data))
+	}
+}
+
+func ExampleAnonymizer_Reader() {
+	a := New(IP4).SetSalt([]byte{})
+	io.Copy(os.Stdout, a.Reader(strings.NewReader("My address is 10.10.1.1\n")))
+	// Output: My address is IP:_M_jR4OvYA8NfVv3cruBTtC5U8R
+}
+
+func ExampleReader() {
+	SetSalt([]byte{})
+	data, _ := io.ReadAll(Reader(strings.NewReader("My address is 10.10.1.1")))
+	fmt.Println(string(data))
+	// Output: My address is IP:_M_jR4OvYA8NfVv3cruBTtC5U8R
+}
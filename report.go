@@ -0,0 +1,177 @@
+/*
+Anon (c) 2023 by Mikhail Kondrashin (mkondrashin@gmail.com)
+github.com/mpkondrashin/anon
+
+report.go
+
+SARIF and JSON lines reports for leaks.
+*/
+package anon
+
+import (
+	"encoding/json"
+	"io"
+	"path/filepath"
+)
+
+// jsonLeak - JSON lines report record. Values themselves are never reported.
+type jsonLeak struct {
+	Path        string `json:"path"`
+	Line        int    `json:"line"`
+	Column      int    `json:"column"`
+	EndColumn   int    `json:"end_column"`
+	Rule        string `json:"rule"`
+	Fingerprint string `json:"fingerprint"`
+	Snippet     string `json:"snippet"`
+}
+
+// WriteJSONLines - write leaks as JSON lines: one JSON object per line with fields
+// path, line, column, end_column, rule, fingerprint and snippet. Fingerprints are
+// keyed by salt, so reports can be published without disclosing the values.
+func WriteJSONLines(w io.Writer, leaks []Leak) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	for _, leak := range leaks {
+		err := encoder.Encode(jsonLeak{
+			Path:        filepath.ToSlash(leak.Path),
+			Line:        leak.Line,
+			Column:      leak.Column,
+			EndColumn:   leak.EndColumn,
+			Rule:        leak.Rule(),
+			Fingerprint: leak.Fingerprint,
+			Snippet:     leak.Snippet,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SARIF 2.1.0 structures. Only fields used by this package are declared.
+
+const (
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion = "2.1.0"
+	sarifToolURI = "https://github.com/mpkondrashin/anon"
+	// sarifFingerprint - key of the leak fingerprint in partialFingerprints. Version is
+	// the version of the Leak.Fingerprint algorithm, as SARIF recommends, and changes only
+	// if fingerprints of the same leaks change.
+	sarifFingerprint = "anonFingerprint/v1"
+)
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool       sarifTool     `json:"tool"`
+	ColumnKind string        `json:"columnKind"`
+	Results    []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID              string            `json:"ruleId"`
+	RuleIndex           int               `json:"ruleIndex"`
+	Level               string            `json:"level"`
+	Message             sarifMessage      `json:"message"`
+	Locations           []sarifLocation   `json:"locations"`
+	PartialFingerprints map[string]string `json:"partialFingerprints"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int           `json:"startLine"`
+	StartColumn int           `json:"startColumn"`
+	EndColumn   int           `json:"endColumn"`
+	Snippet     *sarifMessage `json:"snippet,omitempty"`
+}
+
+// WriteSARIF - write leaks as SARIF 2.1.0 log. Rule IDs are data type names
+// (DataType.String() for built-in types and prefix for custom ones). Leak fingerprints
+// are put to partialFingerprints, so code scanning services can track them between
+// runs made with the same salt.
+func WriteSARIF(w io.Writer, leaks []Leak) error {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           "anon",
+			InformationURI: sarifToolURI,
+			Rules:          []sarifRule{},
+		}},
+		ColumnKind: "unicodeCodePoints",
+		Results:    []sarifResult{},
+	}
+	ruleIndex := make(map[string]int)
+	for _, leak := range leaks {
+		rule := leak.Rule()
+		index, ok := ruleIndex[rule]
+		if !ok {
+			index = len(run.Tool.Driver.Rules)
+			ruleIndex[rule] = index
+			run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{
+				ID:               rule,
+				ShortDescription: sarifMessage{Text: rule + " confidential data"},
+			})
+		}
+		region := sarifRegion{
+			StartLine:   leak.Line,
+			StartColumn: leak.Column,
+			EndColumn:   leak.EndColumn,
+		}
+		if leak.Snippet != "" {
+			region.Snippet = &sarifMessage{Text: leak.Snippet}
+		}
+		run.Results = append(run.Results, sarifResult{
+			RuleID:    rule,
+			RuleIndex: index,
+			Level:     "error",
+			Message:   sarifMessage{Text: rule + " found"},
+			Locations: []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifactLocation{URI: filepath.ToSlash(leak.Path)},
+				Region:           region,
+			}}},
+			PartialFingerprints: map[string]string{sarifFingerprint: leak.Fingerprint},
+		})
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(sarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs:    []sarifRun{run},
+	})
+}
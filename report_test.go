@@ -0,0 +1,103 @@
+/*
+Anon (c) 2023 by Mikhail Kondrashin (mkondrashin@gmail.com)
+github.com/mpkondrashin/anon
+
+report_test.go
+
+Reports testing functions
+*/
+package anon
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func testLeaks(t *testing.T) []Leak {
+	t.Helper()
+	a := New(Email, IP4).SetSalt([]byte("salt"))
+	leaks, err := a.Check("logs/app.log", strings.NewReader("ok\nпочта a@b.com from 10.10.1.1\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return leaks
+}
+
+func TestWriteJSONLines(t *testing.T) {
+	leaks := testLeaks(t)
+	var buf bytes.Buffer
+	if err := WriteJSONLines(&buf, leaks); err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"path":"logs/app.log","line":2,"column":7,"end_column":14,"rule":"Email","fingerprint":"` +
+		leaks[0].Fingerprint + `","snippet":"почта a*****m from 1*******1"}` + "\n" +
+		`{"path":"logs/app.log","line":2,"column":20,"end_column":29,"rule":"IP4","fingerprint":"` +
+		leaks[1].Fingerprint + `","snippet":"почта a*****m from 1*******1"}` + "\n"
+	if buf.String() != expected {
+		t.Errorf("expected\n%s\nbut got\n%s", expected, buf.String())
+	}
+}
+
+func TestWriteSARIF(t *testing.T) {
+	leaks := testLeaks(t)
+	leaks = append(leaks, leaks[0])
+	var buf bytes.Buffer
+	if err := WriteSARIF(&buf, leaks); err != nil {
+		t.Fatal(err)
+	}
+	var log sarifLog
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatal(err)
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("unexpected SARIF log: %s", buf.String())
+	}
+	run := log.Runs[0]
+	if len(run.Tool.Driver.Rules) != 2 || run.Tool.Driver.Rules[0].ID != "Email" || run.Tool.Driver.Rules[1].ID != "IP4" {
+		t.Errorf("unexpected rules: %v", run.Tool.Driver.Rules)
+	}
+	if len(run.Results) != 3 {
+		t.Fatalf("expected 3 results, but got %d", len(run.Results))
+	}
+	result := run.Results[1]
+	location := result.Locations[0].PhysicalLocation
+	if result.RuleID != "IP4" || result.RuleIndex != 1 || location.ArtifactLocation.URI != "logs/app.log" ||
+		location.Region.StartLine != 2 || location.Region.StartColumn != 20 || location.Region.EndColumn != 29 ||
+		result.PartialFingerprints[sarifFingerprint] != leaks[1].Fingerprint {
+		t.Errorf("unexpected result: %+v", result)
+	}
+	if strings.Contains(buf.String(), "a@b.com") {
+		t.Errorf("value is leaked to report")
+	}
+}
+
+func TestReportFingerprints(t *testing.T) {
+	leaks := testLeaks(t)
+	var buf bytes.Buffer
+	if err := WriteSARIF(&buf, leaks); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteJSONLines(&buf, leaks); err != nil {
+		t.Fatal(err)
+	}
+	// Unkeyed hash can be brute forced for low entropy values like IP addresses
+	h := sha256.New()
+	for _, s := range []string{"IP4", "logs/app.log", "10.10.1.1"} {
+		h.Write([]byte(s))
+		h.Write([]byte{0})
+	}
+	if strings.Contains(buf.String(), hex.EncodeToString(h.Sum(nil))) {
+		t.Errorf("unkeyed fingerprint is published")
+	}
+	other, err := New(Email, IP4).SetSalt([]byte("other")).Check("logs/app.log", strings.NewReader("ok\nпочта a@b.com from 10.10.1.1\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other[1].Fingerprint == leaks[1].Fingerprint {
+		t.Errorf("fingerprint does not depend on salt")
+	}
+}
@@ -0,0 +1,183 @@
+/*
+Anon (c) 2023 by Mikhail Kondrashin (mkondrashin@gmail.com)
+github.com/mpkondrashin/anon
+
+scan.go
+
+Single pass scanner for all of the confidential data types.
+*/
+package anon
+
+import (
+	"sort"
+	"strings"
+)
+
+// Finding - confidential data found in the text.
+type Finding struct {
+	// Type - built-in data type of the value. Not valid if Custom is true.
+	Type DataType
+	// Custom - value is found by the data type added by AddConfidentialData or AddDomains.
+	Custom bool
+	// Prefix - prefix of the data type.
+	Prefix string
+	// Start and End - byte offsets of the value in the text.
+	Start int
+	End   int
+	// Value - value itself. Empty unless ScanValues is used.
+	Value string
+	// Replacement - value that would be put instead of the confidential data. Empty if it
+	// can not be known without changing state of the strategy, e.g. number of the value
+	// that was not replaced by Sequential strategy yet.
+	Replacement string
+}
+
+// Rule - return name of the data type: DataType name for built-in types
+// and prefix for custom ones.
+func (f Finding) Rule() string {
+	if f.Custom {
+		return f.Prefix
+	}
+	return f.Type.String()
+}
+
+// Scan - return list of confidential data found in input without modifying it.
+// Findings are sorted by position and do not overlap. Values themselves are not included.
+// Scan has no side effects: nothing is recorded to the vault and counters of the
+// strategies do not change.
+func (a *Anonymizer) Scan(input string) []Finding {
+	return a.findings(input, false)
+}
+
+// ScanValues - same as Scan, but findings include values.
+func (a *Anonymizer) ScanValues(input string) []Finding {
+	return a.findings(input, true)
+}
+
+// findings - return list of findings in input.
+func (a *Anonymizer) findings(input string, withValues bool) []Finding {
+	matches := a.scan(input)
+	result := make([]Finding, len(matches))
+	for i, m := range matches {
+		value := input[m.start:m.end]
+		result[i] = Finding{
+			Type:        m.data.dataType,
+			Custom:      m.data.isCustom(),
+			Prefix:      m.data.prefix,
+			Start:       m.start,
+			End:         m.end,
+			Replacement: a.preview(m.data, value),
+		}
+		if withValues {
+			result[i].Value = value
+		}
+	}
+	return result
+}
+
+// previewer - strategy that changes its state while replacing values. Preview returns
+// replacement without changing the state or false if it is not known yet.
+type previewer interface {
+	preview(a *Anonymizer, prefix, value string) (string, bool)
+}
+
+// preview - return replacement for data of given type without side effects or empty
+// string if it can not be known in advance.
+func (a *Anonymizer) preview(data *confidentialData, s string) string {
+	strategy := a.strategyFor(data)
+	if p, ok := strategy.(previewer); ok {
+		replacement, _ := p.preview(a, data.prefix, s)
+		return replacement
+	}
+	dry := *a
+	dry.vault = nil
+	return strategy.Replace(&dry, data.prefix, s)
+}
+
+// match - piece of confidential data found in the input.
+type match struct {
+	start int
+	end   int
+	data  *confidentialData
+}
+
+func (m match) length() int {
+	return m.end - m.start
+}
+
+// overlaps - return true if m and o share at least one byte of the input.
+func (m match) overlaps(o match) bool {
+	return m.start < o.end && o.start < m.end
+}
+
+// scan - find confidential data of all types in the original input.
+// All of the detectors are run over the same input and overlapping matches
+// are resolved using the following policy: longest match wins; if lengths are
+// equal, detector with higher priority (built-in types in DataType order
+// followed by custom data in order of addition) wins; if still equal, the
+// leftmost match wins. Allowed values (along with values of other types found at
+// the same position) are dropped before resolution, so shorter values found inside
+// of them are still anonymized (like email in the query of allowed URL). Result is
+// sorted by position and has no overlaps.
+func (a *Anonymizer) scan(input string) []match {
+	var candidates []match
+	allowed := make(map[[2]int]struct{})
+	for i := range a.confidentialDataList {
+		data := &a.confidentialDataList[i]
+		for _, loc := range data.find(a, input) {
+			if a.allows(data, input[loc[0]:loc[1]]) {
+				allowed[[2]int{loc[0], loc[1]}] = struct{}{}
+				continue
+			}
+			candidates = append(candidates, match{start: loc[0], end: loc[1], data: data})
+		}
+	}
+	n := 0
+	for _, c := range candidates {
+		if _, ok := allowed[[2]int{c.start, c.end}]; !ok {
+			candidates[n] = c
+			n++
+		}
+	}
+	candidates = candidates[:n]
+	sort.SliceStable(candidates, func(i, j int) bool {
+		ci, cj := candidates[i], candidates[j]
+		if ci.length() != cj.length() {
+			return ci.length() > cj.length()
+		}
+		if ci.data.priority != cj.data.priority {
+			return ci.data.priority < cj.data.priority
+		}
+		return ci.start < cj.start
+	})
+	var result []match
+	for _, c := range candidates {
+		idx := sort.Search(len(result), func(i int) bool {
+			return result[i].end > c.start
+		})
+		if idx < len(result) && result[idx].overlaps(c) {
+			continue
+		}
+		result = append(result, match{})
+		copy(result[idx+1:], result[idx:])
+		result[idx] = c
+	}
+	return result
+}
+
+// rewrite - replace all of the matches in input at once.
+func (a *Anonymizer) rewrite(input string, matches []match) string {
+	if len(matches) == 0 {
+		return input
+	}
+	var sb strings.Builder
+	sb.Grow(len(input))
+	last := 0
+	for _, m := range matches {
+		sb.WriteString(input[last:m.start])
+		sb.WriteString(a.replace(m.data, input[m.start:m.end]))
+		last = m.end
+	}
+	sb.WriteString(input[last:])
+	return sb.String()
+}
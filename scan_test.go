@@ -0,0 +1,79 @@
+/*
+Anon (c) 2023 by Mikhail Kondrashin (mkondrashin@gmail.com)
+github.com/mpkondrashin/anon
+
+scan_test.go
+
+Scan testing functions
+*/
+package anon
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestScan(t *testing.T) {
+	a := New(Email, IP4).SetSalt([]byte{}).
+		AddConfidentialData("ID", regexp.MustCompile(`ID-\d+`), "")
+	input := "ID-1 sent from 10.10.1.1 to a@b.com"
+	expected := []Finding{
+		{Custom: true, Prefix: "ID", Start: 0, End: 4, Value: "ID-1", Replacement: "ID:" + a.Token("ID-1")},
+		{Type: IP4, Prefix: "IP", Start: 15, End: 24, Value: "10.10.1.1", Replacement: "IP:" + a.Token("10.10.1.1")},
+		{Type: Email, Prefix: "Email", Start: 28, End: 35, Value: "a@b.com", Replacement: "Email:" + a.Token("a@b.com")},
+	}
+	actual := a.ScanValues(input)
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected\n%v\nbut got\n%v", expected, actual)
+	}
+	for i := range expected {
+		expected[i].Value = ""
+	}
+	actual = a.Scan(input)
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected\n%v\nbut got\n%v", expected, actual)
+	}
+	if rule := actual[0].Rule(); rule != "ID" {
+		t.Errorf("expected rule ID, but got %s", rule)
+	}
+	if rule := actual[1].Rule(); rule != "IP4" {
+		t.Errorf("expected rule IP4, but got %s", rule)
+	}
+}
+
+func TestScanNoSideEffects(t *testing.T) {
+	vault, err := OpenVault(filepath.Join(t.TempDir(), "vault"), bytes.Repeat([]byte{1}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer vault.Close()
+	a := New(Email, IP4).SetSalt([]byte{}).SetVault(vault).SetStrategy(Sequential(), Email)
+	findings := a.Scan("from 10.10.1.1 to a@b.com")
+	if findings[0].Replacement != "IP:"+a.Token("10.10.1.1") || findings[1].Replacement != "" {
+		t.Errorf("unexpected replacements: %v", findings)
+	}
+	if _, err := vault.Reveal(findings[0].Replacement); !errors.Is(err, ErrTokenNotFound) {
+		t.Errorf("scan recorded value to the vault: %v", err)
+	}
+	if actual := a.Anonymize("b@c.com"); actual != "Email:1" {
+		t.Errorf("scan changed counter: got %s", actual)
+	}
+	if actual := a.Scan("b@c.com")[0].Replacement; actual != "Email:1" {
+		t.Errorf("expected Email:1, but got %s", actual)
+	}
+}
+
+func ExampleAnonymizer_Scan() {
+	a := New(IP4, Email)
+	for _, f := range a.Scan("Message from john@example.com at 10.10.1.1") {
+		fmt.Println(f.Rule(), f.Start, f.End)
+	}
+	// Output:
+	// Email 13 29
+	// IP4 33 42
+}
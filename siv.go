@@ -0,0 +1,357 @@
+/*
+Anon (c) 2023 by Mikhail Kondrashin (mkondrashin@gmail.com)
+github.com/mpkondrashin/anon
+
+siv.go
+
+Deterministic authenticated encryption of values (AES-SIV, RFC 5297).
+*/
+package anon
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrKeyID - will be returned wrapped for invalid or unknown key ID.
+	ErrKeyID = errors.New("invalid key ID")
+	// ErrDecrypt - will be returned wrapped if token can not be decrypted.
+	ErrDecrypt = errors.New("can not decrypt token")
+)
+
+// siv - AES-SIV cipher.
+type siv struct {
+	mac cipher.Block
+	ctr cipher.Block
+	// key - key of the cipher used to derive tenant keys.
+	key []byte
+}
+
+// newSIV - return AES-SIV cipher for 32 bytes (AES-128) or 64 bytes (AES-256) key.
+func newSIV(key []byte) (*siv, error) {
+	if len(key) != 32 && len(key) != 64 {
+		return nil, fmt.Errorf("%w: AES-SIV key should be 32 or 64 bytes long, got %d", ErrKey, len(key))
+	}
+	mac, err := aes.NewCipher(key[:len(key)/2])
+	if err != nil {
+		return nil, err
+	}
+	ctr, err := aes.NewCipher(key[len(key)/2:])
+	if err != nil {
+		return nil, err
+	}
+	return &siv{mac: mac, ctr: ctr, key: key}, nil
+}
+
+// seal - return synthetic IV followed by ciphertext.
+func (s *siv) seal(plaintext []byte, additionalData ...[]byte) []byte {
+	v := s.s2v(plaintext, additionalData...)
+	result := make([]byte, aes.BlockSize+len(plaintext))
+	copy(result, v[:])
+	s.xorCTR(result[aes.BlockSize:], plaintext, v)
+	return result
+}
+
+// open - decrypt and authenticate result of seal.
+func (s *siv) open(ciphertext []byte, additionalData ...[]byte) ([]byte, error) {
+	if len(ciphertext) < aes.BlockSize {
+		return nil, ErrDecrypt
+	}
+	var v [aes.BlockSize]byte
+	copy(v[:], ciphertext)
+	plaintext := make([]byte, len(ciphertext)-aes.BlockSize)
+	s.xorCTR(plaintext, ciphertext[aes.BlockSize:], v)
+	expected := s.s2v(plaintext, additionalData...)
+	if subtle.ConstantTimeCompare(expected[:], v[:]) != 1 {
+		return nil, ErrDecrypt
+	}
+	return plaintext, nil
+}
+
+// xorCTR - encrypt or decrypt data in counter mode with IV derived from v.
+func (s *siv) xorCTR(dst, src []byte, v [aes.BlockSize]byte) {
+	v[8] &= 0x7f
+	v[12] &= 0x7f
+	cipher.NewCTR(s.ctr, v[:]).XORKeyStream(dst, src)
+}
+
+// s2v - S2V function of RFC 5297.
+func (s *siv) s2v(plaintext []byte, additionalData ...[]byte) [aes.BlockSize]byte {
+	var zero [aes.BlockSize]byte
+	d := s.cmac(zero[:])
+	for _, ad := range additionalData {
+		d = dbl(d)
+		xorBlock(&d, s.cmac(ad))
+	}
+	var t []byte
+	if len(plaintext) >= aes.BlockSize {
+		t = append([]byte{}, plaintext...)
+		for i := 0; i < aes.BlockSize; i++ {
+			t[len(t)-aes.BlockSize+i] ^= d[i]
+		}
+	} else {
+		d = dbl(d)
+		var padded [aes.BlockSize]byte
+		copy(padded[:], plaintext)
+		padded[len(plaintext)] = 0x80
+		xorBlock(&d, padded)
+		t = d[:]
+	}
+	return s.cmac(t)
+}
+
+// cmac - AES-CMAC (RFC 4493) of data.
+func (s *siv) cmac(data []byte) [aes.BlockSize]byte {
+	var l [aes.BlockSize]byte
+	s.mac.Encrypt(l[:], l[:])
+	k1 := dbl(l)
+	k2 := dbl(k1)
+	n := (len(data) + aes.BlockSize - 1) / aes.BlockSize
+	complete := n > 0 && len(data)%aes.BlockSize == 0
+	if n == 0 {
+		n = 1
+	}
+	var last [aes.BlockSize]byte
+	if complete {
+		copy(last[:], data[(n-1)*aes.BlockSize:])
+		xorBlock(&last, k1)
+	} else {
+		rest := data[(n-1)*aes.BlockSize:]
+		copy(last[:], rest)
+		last[len(rest)] = 0x80
+		xorBlock(&last, k2)
+	}
+	var x [aes.BlockSize]byte
+	for i := 0; i < n-1; i++ {
+		var block [aes.BlockSize]byte
+		copy(block[:], data[i*aes.BlockSize:])
+		xorBlock(&x, block)
+		s.mac.Encrypt(x[:], x[:])
+	}
+	xorBlock(&x, last)
+	s.mac.Encrypt(x[:], x[:])
+	return x
+}
+
+// dbl - multiply block by x in GF(2^128).
+func dbl(b [aes.BlockSize]byte) [aes.BlockSize]byte {
+	var result [aes.BlockSize]byte
+	carry := b[0] >> 7
+	for i := 0; i < aes.BlockSize-1; i++ {
+		result[i] = b[i]<<1 | b[i+1]>>7
+	}
+	result[aes.BlockSize-1] = b[aes.BlockSize-1]<<1 ^ carry*0x87
+	return result
+}
+
+// xorBlock - xor b into a.
+func xorBlock(a *[aes.BlockSize]byte, b [aes.BlockSize]byte) {
+	for i := range a {
+		a[i] ^= b[i]
+	}
+}
+
+// Encryptor - strategy that replaces values with tokens produced by deterministic
+// authenticated encryption (AES-SIV). Same value always gets the same token, so tokens
+// can be compared like ones produced by Hide, but key holder can decrypt any token
+// without storing any state. Tokens look like "IP:key1.<base64>" and include ID of
+// the key used, so keys can be rotated: new tokens are encrypted with the current
+// key, while all added keys can be used for decryption. Encryptor is safe for
+// concurrent use.
+type Encryptor struct {
+	mx       sync.RWMutex
+	current  string
+	keys     map[string]*siv
+	provider KeyProvider
+	now      func() time.Time
+	err      error
+}
+
+var _ Strategy = &Encryptor{}
+
+// NewEncryptor - return Encryptor with current key. Key should be 32 or 64 bytes long.
+// Key ID should not be empty and can not contain '.' and ':' characters.
+func NewEncryptor(id string, key []byte) (*Encryptor, error) {
+	e := &Encryptor{keys: make(map[string]*siv)}
+	if err := e.AddKey(id, key); err != nil {
+		return nil, err
+	}
+	e.current = id
+	return e, nil
+}
+
+// NewKeyProviderEncryptor - return Encryptor taking keys from provider. Tokens are
+// encrypted with the key provider returns for current time, and decrypted with the key
+// found by ID, so rotating keys can be used. Keys should be 32 or 64 bytes long and
+// have non empty IDs.
+func NewKeyProviderEncryptor(keys KeyProvider) (*Encryptor, error) {
+	e := &Encryptor{
+		keys:     make(map[string]*siv),
+		provider: keys,
+		now:      time.Now,
+	}
+	if _, err := e.siv(keys.Key(e.now())); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// siv - return cipher for the key from provider.
+func (e *Encryptor) siv(key Key) (*siv, error) {
+	e.mx.RLock()
+	s, ok := e.keys[key.ID]
+	e.mx.RUnlock()
+	if ok {
+		return s, nil
+	}
+	if err := e.AddKey(key.ID, key.Value); err != nil {
+		return nil, err
+	}
+	e.mx.RLock()
+	defer e.mx.RUnlock()
+	return e.keys[key.ID], nil
+}
+
+// AddKey - add key that can be used to decrypt tokens.
+func (e *Encryptor) AddKey(id string, key []byte) error {
+	if id == "" || strings.ContainsAny(id, ".:") {
+		return fmt.Errorf("%w: %q", ErrKeyID, id)
+	}
+	s, err := newSIV(key)
+	if err != nil {
+		return err
+	}
+	e.mx.Lock()
+	defer e.mx.Unlock()
+	e.keys[id] = s
+	return nil
+}
+
+// SetCurrentKey - encrypt new tokens with already added key.
+func (e *Encryptor) SetCurrentKey(id string) error {
+	e.mx.Lock()
+	defer e.mx.Unlock()
+	if _, ok := e.keys[id]; !ok {
+		return fmt.Errorf("%w: unknown key %q", ErrKeyID, id)
+	}
+	e.current = id
+	return nil
+}
+
+// Tenant - return Encryptor for given tenant. Its keys are derived from keys of e and
+// tenant ID, so tokens of one tenant can not be decrypted by Encryptor of another one.
+// Anonymizer.Tenant uses it for Encryptor strategies, so tokens produced by tenant
+// anonymizer are decrypted by e.Tenant(id). Keys added to e later are not inherited.
+func (e *Encryptor) Tenant(id string) *Encryptor {
+	t := &Encryptor{keys: make(map[string]*siv), now: e.now}
+	if e.provider != nil {
+		t.provider = tenantKeys{keys: e.provider, tenant: id}
+		return t
+	}
+	e.mx.RLock()
+	defer e.mx.RUnlock()
+	for keyID, s := range e.keys {
+		mac := hmac.New(sha512.New, s.key)
+		mac.Write([]byte("anon tenant " + id))
+		derived, _ := newSIV(mac.Sum(nil)[:len(s.key)])
+		t.keys[keyID] = derived
+	}
+	t.current = e.current
+	return t
+}
+
+// tenant - return Encryptor for given tenant as Strategy.
+func (e *Encryptor) tenant(id string) Strategy {
+	return e.Tenant(id)
+}
+
+// Replace - return encrypted token for the value. If it can not be encrypted because
+// key provider returned invalid key, only prefix is left and error is kept for Err.
+func (e *Encryptor) Replace(a *Anonymizer, prefix, value string) string {
+	return a.render(prefix, e.Encrypt(value))
+}
+
+// Encrypt - return token (without prefix) for the value. Empty string is returned
+// if key provider returned invalid key. Use EncryptE to get the error.
+func (e *Encryptor) Encrypt(value string) string {
+	token, err := e.EncryptE(value)
+	if err != nil {
+		e.mx.Lock()
+		if e.err == nil {
+			e.err = err
+		}
+		e.mx.Unlock()
+	}
+	return token
+}
+
+// EncryptE - return token (without prefix) for the value or error if key provider
+// returned invalid key.
+func (e *Encryptor) EncryptE(value string) (string, error) {
+	if e.provider != nil {
+		key := e.provider.Key(e.now())
+		s, err := e.siv(key)
+		if err != nil {
+			return "", fmt.Errorf("key provider: %w", err)
+		}
+		return key.ID + "." + base64.RawURLEncoding.EncodeToString(s.seal([]byte(value))), nil
+	}
+	e.mx.RLock()
+	defer e.mx.RUnlock()
+	sealed := e.keys[e.current].seal([]byte(value))
+	return e.current + "." + base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+// Err - return first error that occurred while encrypting values by Encrypt or Replace.
+func (e *Encryptor) Err() error {
+	e.mx.RLock()
+	defer e.mx.RUnlock()
+	return e.err
+}
+
+// Decrypt - return original value for the token. Token can be given with or without
+// data type prefix.
+func (e *Encryptor) Decrypt(token string) (string, error) {
+	if i := strings.LastIndexByte(token, ':'); i >= 0 {
+		token = token[i+1:]
+	}
+	id, encoded, found := strings.Cut(token, ".")
+	if !found {
+		return "", fmt.Errorf("%w: missing key ID", ErrDecrypt)
+	}
+	e.mx.RLock()
+	s, ok := e.keys[id]
+	e.mx.RUnlock()
+	if !ok && e.provider != nil {
+		key, err := e.provider.KeyByID(id)
+		if err != nil {
+			return "", err
+		}
+		if s, err = e.siv(key); err != nil {
+			return "", err
+		}
+		ok = true
+	}
+	if !ok {
+		return "", fmt.Errorf("%w: unknown key %q", ErrKeyID, id)
+	}
+	sealed, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrDecrypt, err)
+	}
+	plaintext, err := s.open(sealed)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
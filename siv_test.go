@@ -0,0 +1,126 @@
+/*
+Anon (c) 2023 by Mikhail Kondrashin (mkondrashin@gmail.com)
+github.com/mpkondrashin/anon
+
+siv_test.go
+
+AES-SIV and Encryptor testing functions
+*/
+package anon
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func unhex(s string) []byte {
+	data, err := hex.DecodeString(strings.ReplaceAll(s, " ", ""))
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
+
+func TestSIVReference(t *testing.T) {
+	// RFC 5297 A.1
+	s, err := newSIV(unhex("fffefdfc fbfaf9f8 f7f6f5f4 f3f2f1f0 f0f1f2f3 f4f5f6f7 f8f9fafb fcfdfeff"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ad := unhex("10111213 14151617 18191a1b 1c1d1e1f 20212223 24252627")
+	plaintext := unhex("11223344 55667788 99aabbcc ddee")
+	expected := unhex("85632d07 c6e8f37f 950acd32 0a2ecc93 40c02b96 90c4dc04 daef7f6a fe5c")
+	actual := s.seal(plaintext, ad)
+	if !bytes.Equal(actual, expected) {
+		t.Errorf("expected %x, but got %x", expected, actual)
+	}
+	opened, err := s.open(actual, ad)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(opened, plaintext) {
+		t.Errorf("expected %x, but got %x", plaintext, opened)
+	}
+	actual[len(actual)-1] ^= 1
+	if _, err := s.open(actual, ad); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("expected ErrDecrypt, but got %v", err)
+	}
+}
+
+func TestEncryptor(t *testing.T) {
+	e, err := NewEncryptor("k1", bytes.Repeat([]byte{1}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := New(IP4, Email).SetStrategy(e)
+	input := "from 10.10.1.1 to a@b.com and 10.10.1.1"
+	output := a.Anonymize(input)
+	findings := New(IP4, Email).SetStrategy(e).Scan(input)
+	if findings[0].Replacement != findings[2].Replacement {
+		t.Errorf("same values have different tokens")
+	}
+	if !strings.HasPrefix(findings[0].Replacement, "IP:k1.") {
+		t.Errorf("unexpected token %s", findings[0].Replacement)
+	}
+	if strings.Contains(output, "10.10.1.1") {
+		t.Errorf("value is not encrypted: %s", output)
+	}
+	for _, f := range findings {
+		value, err := e.Decrypt(f.Replacement)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if value != input[f.Start:f.End] {
+			t.Errorf("expected %s, but got %s", input[f.Start:f.End], value)
+		}
+	}
+	oldToken := e.Encrypt("secret")
+	if err := e.AddKey("k2", bytes.Repeat([]byte{2}, 64)); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.SetCurrentKey("k2"); err != nil {
+		t.Fatal(err)
+	}
+	newToken := e.Encrypt("secret")
+	if !strings.HasPrefix(newToken, "k2.") {
+		t.Errorf("token is not encrypted with new key: %s", newToken)
+	}
+	for _, token := range []string{oldToken, newToken} {
+		if value, err := e.Decrypt(token); err != nil || value != "secret" {
+			t.Errorf("%s: expected secret, but got %s, %v", token, value, err)
+		}
+	}
+}
+
+func TestEncryptorErrors(t *testing.T) {
+	if _, err := NewEncryptor("k.1", bytes.Repeat([]byte{1}, 32)); !errors.Is(err, ErrKeyID) {
+		t.Errorf("expected ErrKeyID, but got %v", err)
+	}
+	if _, err := NewEncryptor("k1", bytes.Repeat([]byte{1}, 16)); err == nil {
+		t.Errorf("expected error for wrong key size")
+	}
+	e, _ := NewEncryptor("k1", bytes.Repeat([]byte{1}, 32))
+	if err := e.SetCurrentKey("k2"); !errors.Is(err, ErrKeyID) {
+		t.Errorf("expected ErrKeyID, but got %v", err)
+	}
+	tCases := []struct {
+		token    string
+		expected error
+	}{
+		{"IP:nokey", ErrDecrypt},
+		{"IP:k2.AAAA", ErrKeyID},
+		{"IP:k1.!!!", ErrDecrypt},
+		{"IP:k1.AAAA", ErrDecrypt},
+		{"IP:k1.AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA", ErrDecrypt},
+	}
+	for _, tCase := range tCases {
+		t.Run(tCase.token, func(t *testing.T) {
+			if _, err := e.Decrypt(tCase.token); !errors.Is(err, tCase.expected) {
+				t.Errorf("expected %v, but got %v", tCase.expected, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+/*
+Anon (c) 2023 by Mikhail Kondrashin (mkondrashin@gmail.com)
+github.com/mpkondrashin/anon
+
+slog.go
+
+log/slog handler that anonymizes records.
+*/
+package anon
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+)
+
+// keyRule - how attribute with given key is processed.
+type keyRule int
+
+const (
+	keyDetect keyRule = iota
+	keyHide
+	keySkip
+)
+
+// Handler - slog.Handler that anonymizes message and attributes of the records
+// before passing them to the next handler. String values are anonymized using
+// detectors of the Anonymizer. Rules can be set to always hide or never touch
+// attributes with particular keys. If context passed to Handle has Anonymizer
+// (see NewContext), it is used instead of handler's own one for message and
+// attributes of the record, so per-tenant tokens are produced. Attributes added
+// by WithAttrs are anonymized by handler's own anonymizer.
+type Handler struct {
+	anonymizer *Anonymizer
+	next       slog.Handler
+	rules      map[string]keyRule
+}
+
+var _ slog.Handler = &Handler{}
+
+// Handler - return new slog.Handler that anonymizes records before passing them to next.
+func (a *Anonymizer) Handler(next slog.Handler) *Handler {
+	return &Handler{
+		anonymizer: a,
+		next:       next,
+		rules:      make(map[string]keyRule),
+	}
+}
+
+// HideKeys - always hide values of attributes with given keys regardless of their content.
+// Should be called before handler is used.
+func (h *Handler) HideKeys(keys ...string) *Handler {
+	for _, key := range keys {
+		h.rules[key] = keyHide
+	}
+	return h
+}
+
+// SkipKeys - never anonymize values of attributes with given keys.
+// Should be called before handler is used.
+func (h *Handler) SkipKeys(keys ...string) *Handler {
+	for _, key := range keys {
+		h.rules[key] = keySkip
+	}
+	return h
+}
+
+// Enabled - report whether next handler handles records at the given level.
+func (h *Handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+// Handle - anonymize record and pass it to the next handler.
+func (h *Handler) Handle(ctx context.Context, r slog.Record) error {
+	a := h.anonymizer
+	if ca, ok := FromContext(ctx); ok {
+		a = ca
+	}
+	result := slog.NewRecord(r.Time, r.Level, a.Anonymize(r.Message), r.PC)
+	r.Attrs(func(attr slog.Attr) bool {
+		result.AddAttrs(h.attr(a, attr, keyDetect))
+		return true
+	})
+	return h.next.Handle(ctx, result)
+}
+
+// WithAttrs - return new handler with anonymized attributes added.
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	anonymized := make([]slog.Attr, len(attrs))
+	for i, attr := range attrs {
+		anonymized[i] = h.attr(h.anonymizer, attr, keyDetect)
+	}
+	return h.with(h.next.WithAttrs(anonymized))
+}
+
+// WithGroup - return new handler with given group.
+func (h *Handler) WithGroup(name string) slog.Handler {
+	return h.with(h.next.WithGroup(name))
+}
+
+// with - return copy of handler with different next handler.
+func (h *Handler) with(next slog.Handler) *Handler {
+	return &Handler{
+		anonymizer: h.anonymizer,
+		next:       next,
+		rules:      h.rules,
+	}
+}
+
+// attr - return attribute anonymized by a. Rule of the enclosing group is applied
+// if there is no rule for the attribute key.
+func (h *Handler) attr(a *Anonymizer, attr slog.Attr, rule keyRule) slog.Attr {
+	if r, ok := h.rules[attr.Key]; ok {
+		rule = r
+	}
+	if rule == keySkip {
+		return attr
+	}
+	value := attr.Value.Resolve()
+	switch value.Kind() {
+	case slog.KindGroup:
+		group := value.Group()
+		attrs := make([]any, len(group))
+		for i, each := range group {
+			attrs[i] = h.attr(a, each, rule)
+		}
+		return slog.Group(attr.Key, attrs...)
+	case slog.KindString:
+		if rule == keyHide {
+			return slog.String(attr.Key, a.Hide(value.String()))
+		}
+		return slog.String(attr.Key, a.Anonymize(value.String()))
+	}
+	if rule == keyHide {
+		return slog.String(attr.Key, a.Hide(value.Any()))
+	}
+	if value.Kind() != slog.KindAny {
+		return slog.Attr{Key: attr.Key, Value: value}
+	}
+	s := fmt.Sprintf("%v", value.Any())
+	if anonymized := a.Anonymize(s); anonymized != s {
+		return slog.String(attr.Key, anonymized)
+	}
+	return slog.Attr{Key: attr.Key, Value: value}
+}
+
+// NewHandler - return new slog.Handler that anonymizes records before passing them to next
+// using default anonymizer.
+func NewHandler(next slog.Handler) *Handler {
+	return defaultAnonymizer.Handler(next)
+}
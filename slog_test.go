@@ -0,0 +1,80 @@
+/*
+Anon (c) 2023 by Mikhail Kondrashin (mkondrashin@gmail.com)
+github.com/mpkondrashin/anon
+
+slog_test.go
+
+slog handler testing functions
+*/
+package anon
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"testing"
+)
+
+type user struct {
+	email string
+}
+
+func (u user) LogValue() slog.Value {
+	return slog.GroupValue(slog.String("email", u.email))
+}
+
+func TestHandler(t *testing.T) {
+	a := New(Email, IP4).SetSalt([]byte{})
+	var buf bytes.Buffer
+	h := a.Handler(slog.NewJSONHandler(&buf, nil)).
+		HideKeys("user_id").
+		SkipKeys("request_id")
+	logger := slog.New(h).With("host", "10.10.1.1")
+	logger.WithGroup("req").Info("login from 10.10.1.2",
+		"user_id", 12345,
+		"request_id", "10.10.1.3",
+		"user", user{"john@example.com"},
+		"err", errors.New("no route to 10.10.1.4"),
+		"count", 3,
+	)
+	var record map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("%v: %s", err, buf.String())
+	}
+	req := record["req"].(map[string]any)
+	tCases := []struct {
+		name     string
+		actual   any
+		expected any
+	}{
+		{"msg", record["msg"], "login from IP:" + a.Token("10.10.1.2")},
+		{"with", record["host"], "IP:" + a.Token("10.10.1.1")},
+		{"hide", req["user_id"], a.Token("12345")},
+		{"skip", req["request_id"], "10.10.1.3"},
+		{"valuer", req["user"].(map[string]any)["email"], "Email:" + a.Token("john@example.com")},
+		{"any", req["err"], "no route to IP:" + a.Token("10.10.1.4")},
+		{"number", req["count"], 3.0},
+	}
+	for _, tCase := range tCases {
+		t.Run(tCase.name, func(t *testing.T) {
+			if tCase.actual != tCase.expected {
+				t.Errorf("expected %v, but got %v", tCase.expected, tCase.actual)
+			}
+		})
+	}
+}
+
+func ExampleAnonymizer_Handler() {
+	a := New(IP4).SetSalt([]byte{})
+	removeTime := func(groups []string, a slog.Attr) slog.Attr {
+		if a.Key == slog.TimeKey {
+			return slog.Attr{}
+		}
+		return a
+	}
+	logger := slog.New(a.Handler(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{ReplaceAttr: removeTime})))
+	logger.Info("connected", "address", "10.10.1.1")
+	// Output: level=INFO msg=connected address=IP:_M_jR4OvYA8NfVv3cruBTtC5U8R
+}
@@ -0,0 +1,176 @@
+/*
+Anon (c) 2023 by Mikhail Kondrashin (mkondrashin@gmail.com)
+github.com/mpkondrashin/anon
+
+strategy.go
+
+Replacement strategies for confidential data.
+*/
+package anon
+
+import (
+	"crypto/sha256"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Strategy - the way confidential data is replaced. Replace gets anonymizer
+// that found the value, prefix of the data type and value itself and returns
+// replacement.
+type Strategy interface {
+	Replace(a *Anonymizer, prefix, value string) string
+}
+
+// StrategyFunc - adapter to use ordinary function as Strategy.
+type StrategyFunc func(a *Anonymizer, prefix, value string) string
+
+// Replace - call f(a, prefix, value).
+func (f StrategyFunc) Replace(a *Anonymizer, prefix, value string) string {
+	return f(a, prefix, value)
+}
+
+// HashToken - return strategy that replaces value with prefix and token
+// (like "IP:qTQwwNaStHVodid1n8opcIH2xWo"). This is the default strategy.
+// Replacement format can be changed by Anonymizer.SetTemplate.
+// Only values replaced by this strategy are recorded to the vault, as only its
+// tokens can be revealed.
+func HashToken() Strategy {
+	return StrategyFunc(func(a *Anonymizer, prefix, value string) string {
+		return a.render(prefix, a.token(value))
+	})
+}
+
+// Redact - return strategy that replaces value with fixed text, e.g. "[REDACTED]".
+func Redact(text string) Strategy {
+	return StrategyFunc(func(a *Anonymizer, prefix, value string) string {
+		return text
+	})
+}
+
+// Mask - return strategy that replaces all letters and digits with mask
+// character except last keep ones. Separators are left as is, so
+// "4111-1111-1111-1111" masked with Mask(4, '*') will be "****-****-****-1111".
+func Mask(keep int, mask rune) Strategy {
+	return StrategyFunc(func(a *Anonymizer, prefix, value string) string {
+		runes := []rune(value)
+		kept := 0
+		for i := len(runes) - 1; i >= 0; i-- {
+			if !unicode.IsLetter(runes[i]) && !unicode.IsDigit(runes[i]) {
+				continue
+			}
+			if kept < keep {
+				kept++
+				continue
+			}
+			runes[i] = mask
+		}
+		return string(runes)
+	})
+}
+
+// Truncate - return strategy that keeps only first length characters of the value
+// followed by "...".
+func Truncate(length int) Strategy {
+	return StrategyFunc(func(a *Anonymizer, prefix, value string) string {
+		runes := []rune(value)
+		if len(runes) <= length {
+			return value
+		}
+		return string(runes[:length]) + "..."
+	})
+}
+
+// FormatPreserving - return strategy that replaces value with another value of
+// the same format. IP addresses are changed to other valid addresses of the same
+// family using prefix-preserving Crypto-PAn algorithm. For other values each digit
+// is replaced by digit and each letter by letter of the same case while all other
+// characters are kept. Replacement is keyed by salt.
+func FormatPreserving() Strategy {
+	return StrategyFunc(func(a *Anonymizer, prefix, value string) string {
+		if ip, ok := a.ipCipher(a.currentKey()).anonymizeString(value); ok {
+			return ip
+		}
+		return a.substitute(value)
+	})
+}
+
+// substitute - replace digits and letters of value with keyed pseudo random ones.
+func (a *Anonymizer) substitute(value string) string {
+	var stream []byte
+	block := sha256.Sum256(append([]byte(a.Token(value)), a.currentKey().Value...))
+	next := func() byte {
+		if len(stream) == 0 {
+			block = sha256.Sum256(block[:])
+			stream = block[:]
+		}
+		b := stream[0]
+		stream = stream[1:]
+		return b
+	}
+	var sb strings.Builder
+	for _, c := range value {
+		switch {
+		case c >= '0' && c <= '9':
+			sb.WriteByte('0' + next()%10)
+		case c >= 'a' && c <= 'z':
+			sb.WriteByte('a' + next()%26)
+		case c >= 'A' && c <= 'Z':
+			sb.WriteByte('A' + next()%26)
+		default:
+			sb.WriteRune(c)
+		}
+	}
+	return sb.String()
+}
+
+// sequential - strategy that numbers distinct values of each prefix.
+type sequential struct {
+	mx      sync.Mutex
+	numbers map[string]map[string]int
+}
+
+// Sequential - return strategy that replaces values with prefix and sequential
+// number of the distinct value, e.g. "Email:1", "Email:2". Same value always gets the same
+// number. Counters are kept in memory separately for each prefix and key, so
+// anonymizers of different tenants and different time windows do not share numbers.
+func Sequential() Strategy {
+	return &sequential{
+		numbers: make(map[string]map[string]int),
+	}
+}
+
+// counter - return name of the counter for prefix and current key of a.
+func (s *sequential) counter(a *Anonymizer, prefix string) string {
+	return prefix + "\x00" + string(a.currentKey().Value)
+}
+
+// preview - return prefix and number of the value if it is already assigned.
+func (s *sequential) preview(a *Anonymizer, prefix, value string) (string, bool) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	n, ok := s.numbers[s.counter(a, prefix)][value]
+	if !ok {
+		return "", false
+	}
+	return a.render(prefix, strconv.Itoa(n)), true
+}
+
+// Replace - return prefix and number of the value.
+func (s *sequential) Replace(a *Anonymizer, prefix, value string) string {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	counter := s.counter(a, prefix)
+	numbers, ok := s.numbers[counter]
+	if !ok {
+		numbers = make(map[string]int)
+		s.numbers[counter] = numbers
+	}
+	n, ok := numbers[value]
+	if !ok {
+		n = len(numbers) + 1
+		numbers[value] = n
+	}
+	return a.render(prefix, strconv.Itoa(n))
+}
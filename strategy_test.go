@@ -0,0 +1,80 @@
+/*
+Anon (c) 2023 by Mikhail Kondrashin (mkondrashin@gmail.com)
+github.com/mpkondrashin/anon
+
+strategy_test.go
+
+Replacement strategies testing functions
+*/
+package anon
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+)
+
+func TestStrategies(t *testing.T) {
+	a := New().SetSalt([]byte{})
+	tCases := []struct {
+		name     string
+		strategy Strategy
+		value    string
+		expected string
+	}{
+		{"hash", HashToken(), "secret", "X:" + a.Token("secret")},
+		{"redact", Redact("[REDACTED]"), "secret", "[REDACTED]"},
+		{"mask", Mask(4, '*'), "4111-1111-1111-1234", "****-****-****-1234"},
+		{"mask short", Mask(4, '*'), "12", "12"},
+		{"truncate", Truncate(3), "secret", "sec..."},
+		{"truncate short", Truncate(10), "secret", "secret"},
+		{"format ip4", FormatPreserving(), "192.168.10.25", "131.183.213.249"},
+	}
+	for _, tCase := range tCases {
+		t.Run(tCase.name, func(t *testing.T) {
+			for i := 0; i < 2; i++ {
+				actual := tCase.strategy.Replace(a, "X", tCase.value)
+				if actual != tCase.expected {
+					t.Errorf("%s: expected %s but got %s", tCase.value, tCase.expected, actual)
+				}
+			}
+		})
+	}
+}
+
+func TestFormatPreserving(t *testing.T) {
+	a := New().SetSalt([]byte("salt"))
+	value := "Ab-12.cd"
+	actual := FormatPreserving().Replace(a, "X", value)
+	if !regexp.MustCompile(`^[A-Z][a-z]-\d\d\.[a-z][a-z]$`).MatchString(actual) {
+		t.Errorf("%s: format changed: %s", value, actual)
+	}
+	if again := FormatPreserving().Replace(a, "X", value); again != actual {
+		t.Errorf("%s: expected %s but got %s", value, actual, again)
+	}
+}
+
+func TestSequential(t *testing.T) {
+	a := New(Email, IP4).SetStrategy(Sequential())
+	input := "a@b.com 1.1.1.1 c@d.com a@b.com"
+	expected := "Email:1 IP:1 Email:2 Email:1"
+	actual := a.Anonymize(input)
+	if actual != expected {
+		t.Errorf("For \"%s\", expected \"%s\", but got \"%s\"", input, expected, actual)
+	}
+}
+
+func ExampleAnonymizer_SetStrategy() {
+	a := New(Email, CreditCard).SetSalt([]byte{}).
+		SetStrategy(Mask(4, '*'), CreditCard)
+	fmt.Println(a.Anonymize("Paid by john@example.com with 4111111111111111"))
+	// Output: Paid by Email:9XyOT85L1Clve4KEmwBMlPrtNH_ with ************1111
+}
+
+func ExampleAnonymizer_SetCustomStrategy() {
+	a := New().
+		AddConfidentialData("password", regexp.MustCompile(`password=\S+`), "").
+		SetCustomStrategy(Redact("password=[REDACTED]"), "password")
+	fmt.Println(a.Anonymize("login with password=hunter2"))
+	// Output: login with password=[REDACTED]
+}
@@ -0,0 +1,139 @@
+/*
+Anon (c) 2023 by Mikhail Kondrashin (mkondrashin@gmail.com)
+github.com/mpkondrashin/anon
+
+tenant.go
+
+Per-tenant anonymizers and their propagation through context.Context
+*/
+package anon
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"sync"
+	"time"
+)
+
+// tenantKeys - provider of the keys derived from keys of other provider and tenant ID.
+type tenantKeys struct {
+	keys   KeyProvider
+	tenant string
+}
+
+// Key - return tenant key for given time.
+func (k tenantKeys) Key(t time.Time) Key {
+	return k.derive(k.keys.Key(t))
+}
+
+// KeyByID - return tenant key with given ID.
+func (k tenantKeys) KeyByID(id string) (Key, error) {
+	key, err := k.keys.KeyByID(id)
+	if err != nil {
+		return Key{}, err
+	}
+	return k.derive(key), nil
+}
+
+// derive - return tenant key derived from master key. Key ID is kept unchanged.
+func (k tenantKeys) derive(master Key) Key {
+	mac := hmac.New(sha256.New, master.Value)
+	mac.Write([]byte("anon tenant " + k.tenant))
+	return Key{ID: master.ID, Value: mac.Sum(nil)}
+}
+
+// tenantStrategy - strategy holding its own keys that must be derived for each tenant.
+type tenantStrategy interface {
+	tenant(id string) Strategy
+}
+
+// Tenant - return Anonymizer for given tenant. It has same detectors and settings,
+// but its keys are derived from keys of a and tenant ID, so same values get different
+// tokens for different tenants and can not be correlated across them. Keys of
+// Encryptor strategies are derived the same way (see Encryptor.Tenant). All processes
+// sharing the salt (or key provider) produce same tokens for the same tenant.
+// Anonymizers are created once for each tenant and reused later, so changes made
+// to a after the first call for the tenant do not affect tenant anonymizer.
+func (a *Anonymizer) Tenant(id string) *Anonymizer {
+	if t, ok := a.tenants.Load(id); ok {
+		return t.(*Anonymizer)
+	}
+	t := a.clone()
+	t.SetKeyProvider(tenantKeys{keys: a.keys, tenant: id})
+	t.tenantStrategies(id)
+	actual, _ := a.tenants.LoadOrStore(id, t)
+	return actual.(*Anonymizer)
+}
+
+// tenantStrategies - replace strategies holding keys with their tenant versions.
+func (a *Anonymizer) tenantStrategies(id string) {
+	replaced := make(map[Strategy]Strategy)
+	replace := func(s Strategy) Strategy {
+		ts, ok := s.(tenantStrategy)
+		if !ok {
+			return s
+		}
+		if r, ok := replaced[s]; ok {
+			return r
+		}
+		replaced[s] = ts.tenant(id)
+		return replaced[s]
+	}
+	a.strategy = replace(a.strategy)
+	for i := range a.confidentialDataList {
+		if a.confidentialDataList[i].strategy != nil {
+			a.confidentialDataList[i].strategy = replace(a.confidentialDataList[i].strategy)
+		}
+	}
+}
+
+// clone - return copy of the Anonymizer that can be changed independently.
+func (a *Anonymizer) clone() *Anonymizer {
+	c := *a
+	c.confidentialDataList = append([]confidentialData(nil), a.confidentialDataList...)
+	c.allowAll = a.allowAll.clone()
+	c.allowlists = make(map[DataType]*allowlist, len(a.allowlists))
+	for t, l := range a.allowlists {
+		c.allowlists[t] = l.clone()
+	}
+	c.tenants = &sync.Map{}
+	return &c
+}
+
+// contextKey - key of the Anonymizer in context.Context.
+type contextKey struct{}
+
+// NewContext - return copy of ctx carrying Anonymizer, e.g. one returned by Tenant.
+func NewContext(ctx context.Context, a *Anonymizer) context.Context {
+	return context.WithValue(ctx, contextKey{}, a)
+}
+
+// FromContext - return Anonymizer stored in ctx by NewContext.
+func FromContext(ctx context.Context) (*Anonymizer, bool) {
+	a, ok := ctx.Value(contextKey{}).(*Anonymizer)
+	return a, ok
+}
+
+// fromContext - return Anonymizer stored in ctx or default one.
+func fromContext(ctx context.Context) *Anonymizer {
+	if a, ok := FromContext(ctx); ok {
+		return a
+	}
+	return defaultAnonymizer
+}
+
+// HideContext - anonymize given value with Anonymizer from ctx or default one
+func HideContext(ctx context.Context, v any) string {
+	return fromContext(ctx).Hide(v)
+}
+
+// AnonymizeContext - anonymize all found confidential data with Anonymizer from ctx or default one
+func AnonymizeContext(ctx context.Context, s string) string {
+	return fromContext(ctx).Anonymize(s)
+}
+
+// Tenant - return default Anonymizer for given tenant
+func Tenant(id string) *Anonymizer {
+	return defaultAnonymizer.Tenant(id)
+}
@@ -0,0 +1,122 @@
+/*
+Anon (c) 2023 by Mikhail Kondrashin (mkondrashin@gmail.com)
+github.com/mpkondrashin/anon
+
+tenant_test.go
+
+Per-tenant anonymizers testing functions
+*/
+package anon
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestTenant(t *testing.T) {
+	a := New(IP4, Email).SetSalt([]byte("master")).Allow("127.0.0.1")
+	acme := a.Tenant("acme")
+	if acme != a.Tenant("acme") {
+		t.Error("tenant anonymizer is not reused")
+	}
+	other := New(IP4, Email).SetSalt([]byte("master")).Allow("127.0.0.1").Tenant("acme")
+	initech := a.Tenant("initech")
+	input := "john@example.com from 10.10.1.1 and 127.0.0.1"
+	if acme.Anonymize(input) != other.Anonymize(input) {
+		t.Errorf("same tenant got different tokens")
+	}
+	tokens := map[string]bool{}
+	for _, anonymizer := range []*Anonymizer{a, acme, initech} {
+		tokens[anonymizer.Anonymize(input)] = true
+	}
+	if len(tokens) != 3 {
+		t.Errorf("tenants share tokens: %v", tokens)
+	}
+	acme.Allow("10.10.1.1")
+	if acme.Anonymize("from 10.10.1.1") != "from 10.10.1.1" {
+		t.Error("tenant allowlist is not used")
+	}
+	if a.Anonymize("from 10.10.1.1") == "from 10.10.1.1" {
+		t.Error("tenant changed allowlist of the base anonymizer")
+	}
+	seq := New(Email).SetSalt([]byte("master")).SetStrategy(Sequential())
+	seq.Hide("jane@example.com")
+	if actual := seq.Tenant("acme").Anonymize("john@example.com"); actual != "Email:1" {
+		t.Errorf("expected Email:1, but got %s", actual)
+	}
+}
+
+func TestTenantEncryptor(t *testing.T) {
+	static, err := NewEncryptor("k1", bytes.Repeat([]byte{1}, 64))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rotating, err := RotatingKeys("k", []byte("master"), time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	provider, err := NewKeyProviderEncryptor(rotating)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range []*Encryptor{static, provider} {
+		a := New(IP4).SetSalt([]byte("master")).SetStrategy(e)
+		acme := a.Tenant("acme").Anonymize("10.10.1.1")
+		initech := a.Tenant("initech").Anonymize("10.10.1.1")
+		if acme == initech || acme == a.Anonymize("10.10.1.1") {
+			t.Errorf("tenants share tokens: %s, %s", acme, initech)
+		}
+		if value, err := e.Tenant("acme").Decrypt(acme); err != nil || value != "10.10.1.1" {
+			t.Errorf("expected 10.10.1.1, but got %s, %v", value, err)
+		}
+		if _, err := e.Tenant("initech").Decrypt(acme); err == nil {
+			t.Errorf("token of one tenant is decrypted by another one")
+		}
+		if _, err := e.Decrypt(acme); err == nil {
+			t.Errorf("tenant token is decrypted by base encryptor")
+		}
+	}
+}
+
+func TestContext(t *testing.T) {
+	a := New(IP4).SetSalt([]byte("master"))
+	acme := a.Tenant("acme")
+	ctx := NewContext(context.Background(), acme)
+	if actual, expected := AnonymizeContext(ctx, "from 10.10.1.1"), acme.Anonymize("from 10.10.1.1"); actual != expected {
+		t.Errorf("expected \"%s\", but got \"%s\"", expected, actual)
+	}
+	if actual, expected := HideContext(ctx, 42), acme.Hide(42); actual != expected {
+		t.Errorf("expected \"%s\", but got \"%s\"", expected, actual)
+	}
+	if _, ok := FromContext(context.Background()); ok {
+		t.Error("anonymizer found in empty context")
+	}
+	if actual, expected := HideContext(context.Background(), 42), Hide(42); actual != expected {
+		t.Errorf("expected \"%s\", but got \"%s\"", expected, actual)
+	}
+	var buf bytes.Buffer
+	logger := slog.New(a.Handler(slog.NewJSONHandler(&buf, nil)))
+	logger.InfoContext(ctx, "login from 10.10.1.1", "peer", "10.10.1.2")
+	var record map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("%v: %s", err, buf.String())
+	}
+	if expected := acme.Anonymize("login from 10.10.1.1"); record["msg"] != expected {
+		t.Errorf("expected \"%s\", but got \"%v\"", expected, record["msg"])
+	}
+	if expected := acme.Hide("10.10.1.2"); record["peer"] != expected {
+		t.Errorf("expected \"%s\", but got \"%v\"", expected, record["peer"])
+	}
+}
+
+func ExampleNewContext() {
+	a := New(IP4).SetSalt([]byte("master"))
+	ctx := NewContext(context.Background(), a.Tenant("acme"))
+	fmt.Println(AnonymizeContext(ctx, "from 10.10.1.1") == a.Tenant("acme").Anonymize("from 10.10.1.1"))
+	// Output: true
+}
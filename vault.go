@@ -0,0 +1,172 @@
+/*
+Anon (c) 2023 by Mikhail Kondrashin (mkondrashin@gmail.com)
+github.com/mpkondrashin/anon
+
+vault.go
+
+Encrypted local vault of tokens and original values.
+*/
+package anon
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+var (
+	// ErrTokenNotFound - will be returned wrapped if vault has no record for the token.
+	ErrTokenNotFound = errors.New("token not found")
+	// ErrVaultKey - will be returned wrapped if vault records can not be decrypted with given key.
+	ErrVaultKey = errors.New("wrong vault key or corrupted vault")
+)
+
+// vaultAdditionalData - authenticated data of each vault record.
+var vaultAdditionalData = []byte("anon vault v1")
+
+// Vault - encrypted storage of tokens and original values to reveal them later.
+// Each record is encrypted with AES-GCM and appended to the file as a separate line,
+// so vault is never rewritten. Vault is safe for concurrent use.
+type Vault struct {
+	mx      sync.Mutex
+	file    *os.File
+	aead    cipher.AEAD
+	entries map[string]string
+	err     error
+}
+
+// OpenVault - open or create vault file. Key should be 16, 24 or 32 bytes long
+// (AES-128, AES-192 or AES-256). Existing records are decrypted, so wrong key is
+// reported immediately.
+func OpenVault(path string, key []byte) (*Vault, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("vault: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("vault: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("vault: %w", err)
+	}
+	v := &Vault{
+		file:    file,
+		aead:    aead,
+		entries: make(map[string]string),
+	}
+	if err := v.load(); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("vault: %s: %w", path, err)
+	}
+	return v, nil
+}
+
+// load - read all of the records from the file.
+func (v *Vault) load() error {
+	scanner := bufio.NewScanner(v.file)
+	scanner.Buffer(nil, 1024*1024)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+		token, value, err := v.decrypt(line)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+		v.entries[token] = value
+	}
+	return scanner.Err()
+}
+
+// decrypt - return token and value of the record.
+func (v *Vault) decrypt(line string) (string, string, error) {
+	data, err := base64.RawStdEncoding.DecodeString(line)
+	if err != nil || len(data) < v.aead.NonceSize() {
+		return "", "", ErrVaultKey
+	}
+	nonce, ciphertext := data[:v.aead.NonceSize()], data[v.aead.NonceSize():]
+	plaintext, err := v.aead.Open(nil, nonce, ciphertext, vaultAdditionalData)
+	if err != nil {
+		return "", "", ErrVaultKey
+	}
+	token, value, found := strings.Cut(string(plaintext), "\x00")
+	if !found {
+		return "", "", ErrVaultKey
+	}
+	return token, value, nil
+}
+
+// encrypt - return record for token and value.
+func (v *Vault) encrypt(token, value string) (string, error) {
+	nonce := make([]byte, v.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	data := v.aead.Seal(nonce, nonce, []byte(token+"\x00"+value), vaultAdditionalData)
+	return base64.RawStdEncoding.EncodeToString(data), nil
+}
+
+// Record - store value for the token. Already stored tokens are ignored.
+// Write errors are reported by Err and Close.
+func (v *Vault) Record(token, value string) {
+	v.mx.Lock()
+	defer v.mx.Unlock()
+	if v.err != nil {
+		return
+	}
+	if _, ok := v.entries[token]; ok {
+		return
+	}
+	line, err := v.encrypt(token, value)
+	if err == nil {
+		_, err = v.file.WriteString(line + "\n")
+	}
+	if err != nil {
+		v.err = fmt.Errorf("vault: %w", err)
+		return
+	}
+	v.entries[token] = value
+}
+
+// Reveal - return original value for the token. Token can be given with or without
+// data type prefix ("IP:qTQwwNaStHVodid1n8opcIH2xWo" or "qTQwwNaStHVodid1n8opcIH2xWo").
+func (v *Vault) Reveal(token string) (string, error) {
+	v.mx.Lock()
+	defer v.mx.Unlock()
+	if value, ok := v.entries[token]; ok {
+		return value, nil
+	}
+	if i := strings.LastIndexByte(token, ':'); i >= 0 {
+		if value, ok := v.entries[token[i+1:]]; ok {
+			return value, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s", ErrTokenNotFound, token)
+}
+
+// Err - return first error that occurred while recording values.
+func (v *Vault) Err() error {
+	v.mx.Lock()
+	defer v.mx.Unlock()
+	return v.err
+}
+
+// Close - close vault file and return first error that occurred while recording values.
+func (v *Vault) Close() error {
+	v.mx.Lock()
+	defer v.mx.Unlock()
+	if err := v.file.Close(); err != nil && v.err == nil {
+		v.err = fmt.Errorf("vault: %w", err)
+	}
+	return v.err
+}
@@ -0,0 +1,105 @@
+/*
+Anon (c) 2023 by Mikhail Kondrashin (mkondrashin@gmail.com)
+github.com/mpkondrashin/anon
+
+vault_test.go
+
+Vault testing functions
+*/
+package anon
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestVault(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vault")
+	key := bytes.Repeat([]byte{1}, 32)
+	vault, err := OpenVault(path, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := New(IP4, Email).SetSalt([]byte{}).SetVault(vault)
+	a.Anonymize("from 10.10.1.1 to a@b.com and 10.10.1.1")
+	hidden := a.Hide("my secret")
+	if err := vault.Close(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, []byte("10.10.1.1")) || bytes.Count(data, []byte("\n")) != 3 {
+		t.Errorf("unexpected vault content:\n%s", string(data))
+	}
+	vault, err = OpenVault(path, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer vault.Close()
+	tCases := []struct {
+		token    string
+		expected string
+	}{
+		{"IP:" + a.Token("10.10.1.1"), "10.10.1.1"},
+		{a.Token("a@b.com"), "a@b.com"},
+		{hidden, "my secret"},
+	}
+	for _, tCase := range tCases {
+		t.Run(tCase.token, func(t *testing.T) {
+			actual, err := vault.Reveal(tCase.token)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if actual != tCase.expected {
+				t.Errorf("expected %s, but got %s", tCase.expected, actual)
+			}
+		})
+	}
+	if _, err := vault.Reveal("IP:unknown"); !errors.Is(err, ErrTokenNotFound) {
+		t.Errorf("expected ErrTokenNotFound, but got %v", err)
+	}
+}
+
+func TestVaultWrongKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vault")
+	vault, err := OpenVault(path, bytes.Repeat([]byte{1}, 16))
+	if err != nil {
+		t.Fatal(err)
+	}
+	vault.Record("token", "value")
+	vault.Close()
+	_, err = OpenVault(path, bytes.Repeat([]byte{2}, 16))
+	if !errors.Is(err, ErrVaultKey) {
+		t.Errorf("expected ErrVaultKey, but got %v", err)
+	}
+	if _, err := OpenVault(path, []byte("short")); err == nil {
+		t.Errorf("expected error for wrong key size")
+	}
+}
+
+func TestVaultStrategies(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vault")
+	vault, err := OpenVault(path, bytes.Repeat([]byte{1}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := New(IP4, Email, CreditCard).SetSalt([]byte{}).SetVault(vault).
+		SetStrategy(Redact("[REDACTED]"), Email).
+		SetStrategy(Mask(4, '*'), CreditCard)
+	a.Anonymize("from 10.10.1.1 to a@b.com card 4111 1111 1111 1111")
+	if err := vault.Close(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := bytes.Count(data, []byte("\n")); n != 1 {
+		t.Errorf("expected only IP address to be recorded, but got %d records", n)
+	}
+}
@@ -0,0 +1,91 @@
+/*
+Anon (c) 2023 by Mikhail Kondrashin (mkondrashin@gmail.com)
+github.com/mpkondrashin/anon
+
+window.go
+
+Time windows: tokens stable within the window and different across windows
+*/
+package anon
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrWindow - will be returned wrapped for unknown window names.
+var ErrWindow = errors.New("unknown window")
+
+// Window - period of time tokens are stable within.
+type Window int
+
+const (
+	// NoWindow - tokens do not depend on time.
+	NoWindow Window = iota
+	// Hourly - tokens change at the beginning of each hour.
+	Hourly
+	// Daily - tokens change at midnight.
+	Daily
+	// Weekly - tokens change at midnight from Sunday to Monday.
+	Weekly
+)
+
+// windowNames - names of windows used by String and ParseWindow.
+var windowNames = []string{"none", "hour", "day", "week"}
+
+// String - return name of the window.
+func (w Window) String() string {
+	if w < 0 || int(w) >= len(windowNames) {
+		return fmt.Sprintf("Window(%d)", int(w))
+	}
+	return windowNames[w]
+}
+
+// ParseWindow - return window by its name: "none", "hour", "day" or "week".
+func ParseWindow(name string) (Window, error) {
+	for i, n := range windowNames {
+		if strings.EqualFold(name, n) {
+			return Window(i), nil
+		}
+	}
+	return NoWindow, fmt.Errorf("%w: %s", ErrWindow, name)
+}
+
+// Start - return beginning of the window containing t. Windows are aligned to
+// calendar in UTC, so all of the hosts agree on them regardless of their time zones.
+func (w Window) Start(t time.Time) time.Time {
+	t = t.UTC()
+	switch w {
+	case Hourly:
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, time.UTC)
+	case Daily:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	case Weekly:
+		days := (int(t.Weekday()) + 6) % 7
+		return time.Date(t.Year(), t.Month(), t.Day()-days, 0, 0, 0, 0, time.UTC)
+	}
+	return time.Time{}
+}
+
+// key - return key for the window containing t derived from master key.
+func (w Window) key(master Key, t time.Time) Key {
+	if w == NoWindow {
+		return master
+	}
+	mac := hmac.New(sha256.New, master.Value)
+	mac.Write([]byte("anon window " + w.String() + " " + w.Start(t).Format(time.RFC3339)))
+	return Key{ID: master.ID, Value: mac.Sum(nil)}
+}
+
+// SetWindow - make tokens stable only within given time window. Key for each window
+// is derived from the salt (or key from key provider) and beginning of the window, so
+// processes on different hosts sharing the salt produce same tokens for the same window,
+// while tokens of different windows can not be linked to each other.
+func (a *Anonymizer) SetWindow(window Window) *Anonymizer {
+	a.window = window
+	return a
+}
@@ -0,0 +1,82 @@
+/*
+Anon (c) 2023 by Mikhail Kondrashin (mkondrashin@gmail.com)
+github.com/mpkondrashin/anon
+
+window_test.go
+
+Time windows testing functions
+*/
+package anon
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWindowStart(t *testing.T) {
+	moment := time.Date(2023, 12, 9, 17, 21, 53, 0, time.UTC) // Saturday
+	type tCase struct {
+		window   Window
+		t        time.Time
+		expected time.Time
+	}
+	tCases := []tCase{
+		{Hourly, moment, time.Date(2023, 12, 9, 17, 0, 0, 0, time.UTC)},
+		{Daily, moment, time.Date(2023, 12, 9, 0, 0, 0, 0, time.UTC)},
+		{Weekly, moment, time.Date(2023, 12, 4, 0, 0, 0, 0, time.UTC)},
+		{Weekly, time.Date(2023, 12, 4, 0, 0, 0, 0, time.UTC), time.Date(2023, 12, 4, 0, 0, 0, 0, time.UTC)},
+		{Weekly, time.Date(2023, 12, 3, 23, 59, 0, 0, time.UTC), time.Date(2023, 11, 27, 0, 0, 0, 0, time.UTC)},
+		{Daily, moment.In(time.FixedZone("UTC+10", 10*3600)), time.Date(2023, 12, 9, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tc := range tCases {
+		t.Run(tc.window.String()+" "+tc.t.String(), func(t *testing.T) {
+			actual := tc.window.Start(tc.t)
+			if !actual.Equal(tc.expected) {
+				t.Errorf("expected %v, but got %v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestSetWindow(t *testing.T) {
+	now := time.Date(2023, 12, 9, 10, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+	a := New(IP4).SetSalt([]byte("master")).SetWindow(Daily)
+	b := New(IP4).SetSalt([]byte("master")).SetWindow(Daily)
+	a.now, b.now = clock, clock
+	for _, strategy := range []Strategy{HashToken(), FormatPreserving()} {
+		a.SetStrategy(strategy)
+		b.SetStrategy(strategy)
+		now = time.Date(2023, 12, 9, 10, 0, 0, 0, time.UTC)
+		morning := a.Hide("10.10.1.1")
+		now = now.Add(12 * time.Hour)
+		evening := b.Hide("10.10.1.1")
+		if morning != evening {
+			t.Errorf("tokens differ within window: %s and %s", morning, evening)
+		}
+		now = now.Add(12 * time.Hour)
+		tomorrow := a.Hide("10.10.1.1")
+		if morning == tomorrow {
+			t.Errorf("tokens are equal across windows: %s", morning)
+		}
+	}
+	if unbounded := New(IP4).SetSalt([]byte("master")).Hide("10.10.1.1"); unbounded == a.Hide("10.10.1.1") {
+		t.Errorf("windowed token equals token without window: %s", unbounded)
+	}
+}
+
+func TestParseWindow(t *testing.T) {
+	for _, w := range []Window{NoWindow, Hourly, Daily, Weekly} {
+		parsed, err := ParseWindow(w.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if parsed != w {
+			t.Errorf("expected %v, but got %v", w, parsed)
+		}
+	}
+	if _, err := ParseWindow("month"); !errors.Is(err, ErrWindow) {
+		t.Errorf("expected ErrWindow, but got %v", err)
+	}
+}
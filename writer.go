@@ -0,0 +1,225 @@
+/*
+Anon (c) 2023 by Mikhail Kondrashin (mkondrashin@gmail.com)
+github.com/mpkondrashin/anon
+
+writer.go
+
+Line buffered anonymizing writer.
+*/
+package anon
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// DefaultMaxLineLength - default size of the data buffered while waiting for the end of line.
+const DefaultMaxLineLength = 64 * 1024
+
+// LineWriter - io.WriteCloser comply struct that anonymizes all of the data written into it
+// before passing to the next io.Writer. Data is buffered up to the end of line, so values
+// split across several Write calls are still anonymized. It is safe for concurrent use.
+type LineWriter struct {
+	mx            sync.Mutex
+	anonymyzer    *Anonymizer
+	target        io.Writer
+	buffer        []byte
+	maxLineLength int
+	keys          keyGuard
+}
+
+// Writer - return new LineWriter to anonymize data before writing to the target io.Writer.
+// Incomplete line is kept in memory until newline is written, Flush or Close is called.
+func (a *Anonymizer) Writer(target io.Writer) *LineWriter {
+	return &LineWriter{
+		anonymyzer:    a,
+		target:        target,
+		maxLineLength: a.lineLength(),
+	}
+}
+
+// SetMaxLineLength - set maximum size of the incomplete line kept in memory by Writer and
+// Reader. When it is exceeded, data is processed up to the last whitespace character (or
+// as is if there is none). It should be greater than the longest expected confidential value.
+// Zero means DefaultMaxLineLength.
+func (a *Anonymizer) SetMaxLineLength(length int) *Anonymizer {
+	a.maxLineLength = length
+	return a
+}
+
+// lineLength - return maximum size of the incomplete line.
+func (a *Anonymizer) lineLength() int {
+	if a.maxLineLength <= 0 {
+		return DefaultMaxLineLength
+	}
+	return a.maxLineLength
+}
+
+// Write - anonymize complete lines and write them to the target io.Writer.
+// The rest of the data is kept until next Write, Flush or Close. Data is always consumed
+// as a whole, so len(p) is returned even if writing to the target fails.
+func (w *LineWriter) Write(p []byte) (n int, err error) {
+	w.mx.Lock()
+	defer w.mx.Unlock()
+	w.buffer = append(w.buffer, p...)
+	return len(p), w.write(w.keys.cut(w.anonymyzer, w.buffer, w.maxLineLength))
+}
+
+// Flush - anonymize and write all of the buffered data, including incomplete line.
+func (w *LineWriter) Flush() error {
+	w.mx.Lock()
+	defer w.mx.Unlock()
+	return w.write(len(w.buffer))
+}
+
+// Close - flush buffered data. Target io.Writer is not closed.
+func (w *LineWriter) Close() error {
+	return w.Flush()
+}
+
+// write - anonymize and write first n bytes of the buffer.
+func (w *LineWriter) write(n int) error {
+	if n == 0 {
+		return nil
+	}
+	s := w.keys.anonymize(w.anonymyzer, string(w.buffer[:n]), w.maxLineLength)
+	w.buffer = w.buffer[:copy(w.buffer, w.buffer[n:])]
+	_, err := io.WriteString(w.target, s)
+	return err
+}
+
+// safeCut - return length of the data that can be anonymized without splitting
+// confidential values: up to the last newline or, if data exceeds maxLength,
+// up to the last whitespace. Zero means nothing can be processed yet.
+func safeCut(data []byte, maxLength int) int {
+	if i := bytes.LastIndexByte(data, '\n'); i >= 0 {
+		return i + 1
+	}
+	if len(data) < maxLength {
+		return 0
+	}
+	if i := bytes.LastIndexAny(data, " \t\r\v\f"); i >= 0 {
+		return i + 1
+	}
+	return len(data)
+}
+
+// Private key block markers and lines of the block: base64 data or encryption headers.
+var (
+	rxKeyBegin = regexp.MustCompile(`-----BEGIN (?:[A-Z0-9]+ )*PRIVATE KEY-----`)
+	rxKeyEnd   = regexp.MustCompile(`-----END (?:[A-Z0-9]+ )*PRIVATE KEY-----`)
+	rxKeyLine  = regexp.MustCompile(`^(?:[A-Za-z0-9+/=]*|Proc-Type: [^\r\n]*|DEK-Info: [^\r\n]*)\r?$`)
+)
+
+// maxKeySize - minimum number of bytes of the private key block dropped after its beginning,
+// when the block does not fit into maximum line length. The largest PEM keys (RSA 8192)
+// are about 6.5 KB long.
+const maxKeySize = 16 * 1024
+
+// keyGuard - keeps lines of private key block together, so Writer and Reader anonymize
+// it as a whole instead of passing base64 lines following the first one as is.
+type keyGuard struct {
+	// left - number of bytes of the key block that did not fit into maximum line length
+	// still to be dropped. Dropping stops earlier on the end line or on the first line
+	// that does not look like a part of the key.
+	left int
+}
+
+// keyContent - return true if all complete lines of s look like lines of private key block.
+func keyContent(s string) bool {
+	lines := strings.Split(s, "\n")
+	for _, line := range lines[:len(lines)-1] {
+		if !rxKeyLine.MatchString(line) {
+			return false
+		}
+	}
+	return true
+}
+
+// openKey - return position of the beginning of the private key block that is not
+// complete in s or -1 if there is none. Marker followed by text on the same line or by
+// lines that are not a part of the key (like in "file must start with -----BEGIN PRIVATE
+// KEY-----" log message) does not begin a block.
+func openKey(s string) int {
+	begins := rxKeyBegin.FindAllStringIndex(s, -1)
+	if len(begins) == 0 {
+		return -1
+	}
+	last := begins[len(begins)-1]
+	rest := s[last[1]:]
+	if rxKeyEnd.MatchString(rest) {
+		return -1
+	}
+	rest = strings.TrimPrefix(rest, "\r")
+	if rest != "" && rest[0] != '\n' || !keyContent(rest) {
+		return -1
+	}
+	return last[0]
+}
+
+// cut - return length of the data that can be anonymized like safeCut, but do not
+// split private key block unless it exceeds maxLength.
+func (g *keyGuard) cut(a *Anonymizer, data []byte, maxLength int) int {
+	cut := safeCut(data, maxLength)
+	if cut == 0 || g.left > 0 || !a.detects(PrivateKey) || !bytes.Contains(data[:cut], []byte("PRIVATE KEY-----")) {
+		return cut
+	}
+	begin := openKey(string(data[:cut]))
+	if begin < 0 {
+		return cut
+	}
+	if line := bytes.LastIndexByte(data[:begin], '\n') + 1; line > 0 || len(data) < maxLength {
+		return line
+	}
+	return cut
+}
+
+// anonymize - anonymize data. If it ends inside of private key block, only its first
+// line is anonymized and the rest of the block is dropped (see keyGuard).
+func (g *keyGuard) anonymize(a *Anonymizer, data string, maxLength int) string {
+	if g.left > 0 {
+		data = g.drop(data)
+		if data == "" {
+			return ""
+		}
+	}
+	if !a.detects(PrivateKey) {
+		return a.Anonymize(data)
+	}
+	begin := openKey(data)
+	if begin < 0 {
+		return a.Anonymize(data)
+	}
+	g.left = maxLength
+	if g.left < maxKeySize {
+		g.left = maxKeySize
+	}
+	return a.Anonymize(data[:begin+len(rxKeyBegin.FindString(data[begin:]))])
+}
+
+// drop - drop lines of the private key block from the beginning of data and return the rest.
+func (g *keyGuard) drop(data string) string {
+	for data != "" && g.left > 0 {
+		line, rest, found := strings.Cut(data, "\n")
+		if end := rxKeyEnd.FindStringIndex(line); end != nil {
+			g.left = 0
+			return data[end[1]:]
+		}
+		if !rxKeyLine.MatchString(line) {
+			g.left = 0
+			return data
+		}
+		g.left -= len(line)
+		if found {
+			g.left--
+		}
+		data = rest
+	}
+	if g.left < 0 {
+		g.left = 0
+	}
+	return data
+}